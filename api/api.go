@@ -0,0 +1,9 @@
+// Package api contains API definitions of heroes application
+package api
+
+import _ "embed"
+
+// OpenAPI is OpenAPI 3 document describing every http route
+//
+//go:embed openapi.json
+var OpenAPI []byte
@@ -1,32 +1,215 @@
 package main
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
 	"github.com/bliuchak/heroes/internal"
 	"github.com/bliuchak/heroes/internal/config"
+	"github.com/bliuchak/heroes/internal/ratelimit"
+	"github.com/bliuchak/heroes/internal/server/middleware"
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
 var (
-	appport    = kingpin.Flag("appport", "port where to run app").Envar("APP_PORT").Default("3001").Int()
+	appport  = kingpin.Flag("appport", "port where to run app").Envar("APP_PORT").Default("3001").Int()
+	grpcport = kingpin.Flag("grpcport", "port where to run gRPC server").Envar("GRPC_PORT").Default("3002").Int()
+
+	shutdowndelay = kingpin.Flag("shutdowndelay", "how long to serve after readiness is failed on shutdown").Envar("SHUTDOWN_DELAY").Default("0s").Duration()
+	shutdowngrace = kingpin.Flag("shutdowngrace", "how long to drain in-flight requests on shutdown").Envar("SHUTDOWN_GRACE").Default("30s").Duration()
+	readytimeout  = kingpin.Flag("readytimeout", "max duration of dependency check in readiness probe").Envar("READY_TIMEOUT").Default("1s").Duration()
+	debug         = kingpin.Flag("debug", "send stack trace of panic in response, for local development only").Envar("DEBUG").Default("false").Bool()
+
 	dbhost     = kingpin.Flag("dbhost", "storage host").Envar("DB_HOST").String()
 	dbport     = kingpin.Flag("dbport", "storage port").Envar("DB_PORT").String()
 	dbpassword = kingpin.Flag("dbpassword", "storage password").Envar("DB_PASSWORD").String()
+	dbcoalesce = kingpin.Flag("dbcoalesce", "share single storage call between concurrent identical reads").Envar("DB_COALESCE").Default("true").Bool()
+	dbtimeout  = kingpin.Flag("dbtimeout", "max duration of single storage call, 0 disables it").Envar("DB_TIMEOUT").Default("5s").Duration()
+
+	authenabled = kingpin.Flag("auth", "require API key on hero and admin routes").Envar("AUTH_ENABLED").Default("true").Bool()
+	adminkey    = kingpin.Flag("adminkey", "API key with admin scope used to create the first keys").Envar("ADMIN_KEY").String()
+
+	jwthmacfile   = kingpin.Flag("jwthmacfile", "file with HS256 secret of JWT").Envar("JWT_HMAC_FILE").String()
+	jwtpublickey  = kingpin.Flag("jwtpublickey", "PEM file with RS256/ES256 public key of JWT").Envar("JWT_PUBLIC_KEY_FILE").String()
+	jwtjwks       = kingpin.Flag("jwtjwks", "JWKS file with keys of JWT").Envar("JWT_JWKS_FILE").String()
+	jwtissuer     = kingpin.Flag("jwtissuer", "required iss claim of JWT").Envar("JWT_ISSUER").String()
+	jwtaudience   = kingpin.Flag("jwtaudience", "required aud claim of JWT").Envar("JWT_AUDIENCE").String()
+	jwtrolesclaim = kingpin.Flag("jwtrolesclaim", "claim of JWT with roles (viewer, editor, admin)").Envar("JWT_ROLES_CLAIM").Default("roles").String()
+	jwtleeway     = kingpin.Flag("jwtleeway", "allowed clock skew of JWT expiry checks").Envar("JWT_LEEWAY").Default("30s").Duration()
+
+	ratelimitbackend = kingpin.Flag("ratelimit", "rate limiter (redis, local)").Envar("RATE_LIMIT_BACKEND").Default("redis").Enum("redis", "local")
+	ratelimitdefault = kingpin.Flag("ratelimitdefault", "requests allowed per client and route, e.g. 300/1m, 0/1m disables it").Envar("RATE_LIMIT").Default("300/1m").String()
+	ratelimitroutes  = kingpin.Flag("ratelimitroutes", "limits of v1 routes, deprecated aliases share them, e.g. \"GET /v1/heroes=60/1m\"").Envar("RATE_LIMIT_ROUTES").Default("GET /v1/heroes=60/1m").String()
+	ratelimitclients = kingpin.Flag("ratelimitclients", "limits of clients, e.g. \"apikey:1a2b=1000/1m\"").Envar("RATE_LIMIT_CLIENTS").String()
+	ratelimitip      = kingpin.Flag("ratelimitip", "requests allowed per IP on all routes before authentication, 0/1m disables it").Envar("RATE_LIMIT_IP").Default("3000/1m").String()
+
+	corsorigins     = kingpin.Flag("corsorigins", "comma separated origins allowed to call API, e.g. https://*.example.com, empty disables CORS").Envar("CORS_ORIGINS").String()
+	corsmethods     = kingpin.Flag("corsmethods", "comma separated methods allowed to cross-origin requests").Envar("CORS_METHODS").Default("GET,POST,PUT,DELETE").String()
+	corsheaders     = kingpin.Flag("corsheaders", "comma separated headers allowed to cross-origin requests").Envar("CORS_HEADERS").Default("Accept,Content-Type,Authorization,X-API-Key,X-Request-ID").String()
+	corscredentials = kingpin.Flag("corscredentials", "allow cross-origin requests with credentials").Envar("CORS_CREDENTIALS").Default("false").Bool()
+	corsmaxage      = kingpin.Flag("corsmaxage", "how long browser may cache preflight response").Envar("CORS_MAX_AGE").Default("10m").Duration()
+
+	accesslogsample = kingpin.Flag("accesslogsample", "log only every n-th successful request, failed requests are always logged").Envar("ACCESS_LOG_SAMPLE").Default("1").Int()
+	trustedproxies  = kingpin.Flag("trustedproxies", "comma separated networks of proxies trusted to set X-Forwarded-For, e.g. 10.0.0.0/8").Envar("TRUSTED_PROXIES").String()
+
+	tracingexporter = kingpin.Flag("tracingexporter", "exporter of spans (none, stdout, file, otlp)").Envar("TRACING_EXPORTER").Default("none").String()
+	tracingtarget   = kingpin.Flag("tracingtarget", "file of file exporter or collector URL of otlp exporter, e.g. http://localhost:4318").Envar("TRACING_TARGET").String()
+	tracingsample   = kingpin.Flag("tracingsample", "share of new traces which are sampled (0-1)").Envar("TRACING_SAMPLE_RATIO").Default("1").Float64()
+
+	notifierbackend = kingpin.Flag("notifier", "storage events notifier (redis, local)").Envar("NOTIFIER").Default("redis").Enum("redis", "local")
+	notifierchannel = kingpin.Flag("notifierchannel", "redis channel for storage events").Envar("NOTIFIER_CHANNEL").Default("heroes.events").String()
+
+	cachesize    = kingpin.Flag("cachesize", "max number of cached heroes, 0 disables cache").Envar("CACHE_SIZE").Default("0").Int()
+	cachettl     = kingpin.Flag("cachettl", "how long single hero is cached").Envar("CACHE_TTL").Default("30s").Duration()
+	cachelistttl = kingpin.Flag("cachelistttl", "how long list of heroes is cached, 0 disables it").Envar("CACHE_LIST_TTL").Default("0s").Duration()
+
+	legacydeprecation = kingpin.Flag("legacydeprecation", "date when unversioned routes were deprecated (YYYY-MM-DD)").Envar("LEGACY_DEPRECATION").Default("2026-10-19").String()
+	legacysunset      = kingpin.Flag("legacysunset", "date when unversioned routes will be removed (YYYY-MM-DD)").Envar("LEGACY_SUNSET").Default("2027-04-19").String()
 )
 
 func main() {
 	kingpin.Parse()
 
 	conf := config.NewConfig(*appport, *dbhost, *dbport, *dbpassword)
+	conf.Database.Coalesce = *dbcoalesce
+	conf.Database.Timeout = *dbtimeout
+	conf.GRPC.Port = *grpcport
+	conf.Server.ShutdownDelay = *shutdowndelay
+	conf.Server.ShutdownGrace = *shutdowngrace
+	conf.Server.ReadinessTimeout = *readytimeout
+	conf.Server.Debug = *debug
+
+	var err error
+	conf.Server.LegacyDeprecatedAt, err = time.Parse("2006-01-02", *legacydeprecation)
+	kingpin.FatalIfError(err, "invalid legacydeprecation")
+	conf.Server.LegacySunset, err = time.Parse("2006-01-02", *legacysunset)
+	kingpin.FatalIfError(err, "invalid legacysunset")
+
+	conf.Notifier = config.Notifier{
+		Backend: *notifierbackend,
+		Channel: *notifierchannel,
+	}
+	conf.Auth = config.Auth{
+		Enabled:  *authenabled,
+		AdminKey: *adminkey,
+	}
+	conf.JWT = config.JWT{
+		HMACKeyFile:   *jwthmacfile,
+		PublicKeyFile: *jwtpublickey,
+		JWKSFile:      *jwtjwks,
+		Issuer:        *jwtissuer,
+		Audience:      *jwtaudience,
+		RolesClaim:    *jwtrolesclaim,
+		Leeway:        *jwtleeway,
+	}
+	conf.RateLimit.Backend = *ratelimitbackend
+	conf.RateLimit.Default, err = ratelimit.ParseLimit(*ratelimitdefault)
+	kingpin.FatalIfError(err, "invalid ratelimitdefault")
+	conf.RateLimit.Routes, err = ratelimit.ParseLimits(*ratelimitroutes)
+	kingpin.FatalIfError(err, "invalid ratelimitroutes")
+	conf.RateLimit.Clients, err = ratelimit.ParseLimits(*ratelimitclients)
+	kingpin.FatalIfError(err, "invalid ratelimitclients")
+	conf.RateLimit.IP, err = ratelimit.ParseLimit(*ratelimitip)
+	kingpin.FatalIfError(err, "invalid ratelimitip")
+	conf.CORS = config.CORS{
+		Origins:     splitList(*corsorigins),
+		Methods:     splitList(*corsmethods),
+		Headers:     splitList(*corsheaders),
+		Credentials: *corscredentials,
+		MaxAge:      *corsmaxage,
+	}
+	err = middleware.CORSOptions{AllowedOrigins: conf.CORS.Origins, AllowCredentials: conf.CORS.Credentials}.Validate()
+	kingpin.FatalIfError(err, "invalid corscredentials")
+	conf.AccessLog.Sample = *accesslogsample
+	conf.AccessLog.TrustedProxies, err = middleware.ParsePrefixes(*trustedproxies)
+	kingpin.FatalIfError(err, "invalid trustedproxies")
+	conf.Tracing = config.Tracing{
+		Exporter:    *tracingexporter,
+		Target:      *tracingtarget,
+		SampleRatio: *tracingsample,
+	}
+	conf.Cache = config.Cache{
+		Size:    *cachesize,
+		TTL:     *cachettl,
+		ListTTL: *cachelistttl,
+	}
 	app := heroes.NewApplication(*conf)
 
 	app.InitLogger()
-	err := app.InitStorage()
+	err = app.InitTracing(context.Background())
+	if err != nil {
+		app.Logger.Error().Err(err).Msg("Unable to init tracing")
+	}
+
+	err = app.InitNotifier()
+	if err != nil {
+		app.Logger.Error().Err(err).Msg("Unable to init notifier")
+	}
+
+	err = app.InitStorage()
 	if err != nil {
 		app.Logger.Error().Err(err).Msg("Unable to init storage")
 	}
 
-	err = app.Run()
+	err = app.InitRateLimiter()
 	if err != nil {
-		app.Logger.Error().Err(err).Msg("Unable to run app")
+		app.Logger.Error().Err(err).Msg("Unable to init rate limiter")
+	}
+
+	err = app.InitJWT()
+	if err != nil {
+		app.Logger.Error().Err(err).Msg("Unable to init JWT verification")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	app.InitServers()
+
+	// SIGUSR1 puts app into maintenance mode, SIGUSR2 brings it back
+	maintenance := make(chan os.Signal, 1)
+	signal.Notify(maintenance, syscall.SIGUSR1, syscall.SIGUSR2)
+	defer signal.Stop(maintenance)
+	go func() {
+		for sig := range maintenance {
+			app.SetMaintenance(sig == syscall.SIGUSR1)
+		}
+	}()
+
+	runErr := make(chan error, 1)
+	go func() { runErr <- app.Run() }()
+
+	select {
+	case err = <-runErr:
+		if err != nil {
+			app.Logger.Error().Err(err).Msg("Unable to run app")
+		}
+	case <-ctx.Done():
+		app.Logger.Info().Msg("Shutdown signal received")
+	}
+	// second signal kills app immediately
+	stop()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), conf.Server.ShutdownDelay+conf.Server.ShutdownGrace)
+	defer cancel()
+
+	err = app.Shutdown(shutdownCtx)
+	if err != nil {
+		app.Logger.Error().Err(err).Msg("Unable to shutdown app")
+	}
+}
+
+// splitList splits comma separated list, empty items are skipped
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
@@ -0,0 +1,73 @@
+// Package apierror describes storage errors to API clients, it's shared by
+// REST, gRPC and GraphQL, so every transport tells clients the same and
+// never exposes driver messages
+package apierror
+
+import (
+	"context"
+	"errors"
+
+	"github.com/bliuchak/heroes/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+// Error is client-safe description of error
+type Error struct {
+	Kind storage.Kind
+	// Message is safe to send to client, it never contains driver message
+	Message string
+	// Timeout tells that storage didn't answer in time
+	Timeout bool
+}
+
+// FromError describes err to client
+func FromError(err error) Error {
+	e := Error{Kind: storage.KindOf(err)}
+	if errors.Is(err, context.DeadlineExceeded) {
+		e.Timeout = true
+		e.Message = "storage didn't respond in time"
+		return e
+	}
+
+	switch e.Kind {
+	case storage.ErrNotFound:
+		e.Message = heroDetail(err, "doesn't exist")
+	case storage.ErrConflict:
+		e.Message = heroDetail(err, "conflicts with stored data")
+	case storage.ErrPreconditionFailed:
+		e.Message = heroDetail(err, "doesn't match precondition")
+	case storage.ErrInvalid:
+		e.Message = heroDetail(err, "is refused by storage")
+	case storage.ErrForbidden:
+		e.Message = heroDetail(err, "is owned by other client")
+	case storage.ErrUnavailable:
+		e.Message = "storage is unavailable"
+	default:
+		e.Message = "internal error"
+	}
+	return e
+}
+
+// Failed reports whether error is failure of server rather than answer to
+// client, such errors have to be logged
+func (e Error) Failed() bool {
+	return e.Kind == storage.ErrInternal || e.Kind == storage.ErrUnavailable
+}
+
+// Log logs err with its driver message when it's failure of server
+func Log(logger *zerolog.Logger, err error, msg string) Error {
+	e := FromError(err)
+	if e.Failed() {
+		logger.Error().Err(err).Msg(msg)
+	}
+	return e
+}
+
+// heroDetail describes storage error without exposing driver message
+func heroDetail(err error, what string) string {
+	var e *storage.Error
+	if errors.As(err, &e) && e.ID != "" {
+		return "hero " + e.ID + " " + what
+	}
+	return "hero " + what
+}
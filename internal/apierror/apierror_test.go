@@ -0,0 +1,76 @@
+package apierror
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bliuchak/heroes/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromError(t *testing.T) {
+	driver := errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")
+
+	tests := []struct {
+		name     string
+		err      error
+		expected Error
+	}{
+		{
+			name:     "should describe not found hero",
+			err:      storage.NewError(storage.ErrNotFound, "GetHero", "1", driver),
+			expected: Error{Kind: storage.ErrNotFound, Message: "hero 1 doesn't exist"},
+		},
+		{
+			name:     "should describe conflict",
+			err:      storage.NewError(storage.ErrConflict, "CreateHero", "1", driver),
+			expected: Error{Kind: storage.ErrConflict, Message: "hero 1 conflicts with stored data"},
+		},
+		{
+			name:     "should describe precondition",
+			err:      storage.NewError(storage.ErrPreconditionFailed, "UpdateHero", "1", driver),
+			expected: Error{Kind: storage.ErrPreconditionFailed, Message: "hero 1 doesn't match precondition"},
+		},
+		{
+			name:     "should describe invalid hero",
+			err:      storage.NewError(storage.ErrInvalid, "CreateHero", "", driver),
+			expected: Error{Kind: storage.ErrInvalid, Message: "hero is refused by storage"},
+		},
+		{
+			name:     "should describe forbidden hero",
+			err:      storage.NewError(storage.ErrForbidden, "DeleteHero", "1", driver),
+			expected: Error{Kind: storage.ErrForbidden, Message: "hero 1 is owned by other client"},
+		},
+		{
+			name:     "should describe unavailable storage",
+			err:      storage.NewError(storage.ErrUnavailable, "GetHero", "1", driver),
+			expected: Error{Kind: storage.ErrUnavailable, Message: "storage is unavailable"},
+		},
+		{
+			name:     "should describe timeout",
+			err:      storage.NewError(storage.ErrUnavailable, "GetHero", "1", context.DeadlineExceeded),
+			expected: Error{Kind: storage.ErrUnavailable, Message: "storage didn't respond in time", Timeout: true},
+		},
+		{
+			name:     "should hide unknown error",
+			err:      driver,
+			expected: Error{Kind: storage.ErrInternal, Message: "internal error"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := FromError(tt.err)
+
+			assert.Equal(t, tt.expected, e)
+			assert.NotContains(t, e.Message, "WRONGTYPE")
+		})
+	}
+}
+
+func TestError_Failed(t *testing.T) {
+	assert.True(t, Error{Kind: storage.ErrInternal}.Failed())
+	assert.True(t, Error{Kind: storage.ErrUnavailable}.Failed())
+	assert.False(t, Error{Kind: storage.ErrNotFound}.Failed())
+	assert.False(t, Error{Kind: storage.ErrForbidden}.Failed())
+}
@@ -1,33 +1,89 @@
 package heroes
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/bliuchak/heroes/internal/auth"
 	"github.com/bliuchak/heroes/internal/config"
 	"github.com/bliuchak/heroes/internal/db"
+	"github.com/bliuchak/heroes/internal/grpcserver"
+	"github.com/bliuchak/heroes/internal/health"
+	"github.com/bliuchak/heroes/internal/metrics"
+	"github.com/bliuchak/heroes/internal/notifier"
+	"github.com/bliuchak/heroes/internal/ratelimit"
 	"github.com/bliuchak/heroes/internal/server"
 	"github.com/bliuchak/heroes/internal/storage"
+	"github.com/bliuchak/heroes/internal/storage/cache"
+	"github.com/bliuchak/heroes/internal/storage/coalesce"
+	"github.com/bliuchak/heroes/internal/storage/instrument"
+	"github.com/bliuchak/heroes/internal/tracing"
 	"github.com/rs/zerolog"
 )
 
 // App is an application container with necessary dependencies
 type App struct {
-	Logger  zerolog.Logger
-	Storage storage.Storager
-	Server  server.Serverer
-	Config  config.Config
+	Logger     zerolog.Logger
+	Storage    storage.Storager
+	Keys       storage.KeyStorager
+	JWT        *auth.JWTVerifier
+	Limiter    ratelimit.Limiter
+	Metrics    *metrics.Metrics
+	Notifier   notifier.Notifier
+	Server     server.Serverer
+	GRPCServer *grpcserver.Server
+	Config     config.Config
+
+	// redis is connection shared by storage and rate limiter
+	redis *db.Redis
+	// logOutput is flushed on shutdown
+	logOutput *os.File
+	// stopTracing flushes pending spans on shutdown
+	stopTracing func(context.Context) error
+	// shuttingDown tells Run that servers stop because of Shutdown,
+	// so they are left draining instead of being closed
+	shuttingDown atomic.Bool
 }
 
 // NewApplication returns pointer to App structure with filled data
 func NewApplication(config config.Config) *App {
 	return &App{
-		Config: config,
+		Config:  config,
+		Metrics: metrics.New(),
 	}
 }
 
 // InitLogger sets logger to App structure
 func (a *App) InitLogger() {
-	a.Logger = zerolog.New(os.Stdout).Output(zerolog.ConsoleWriter{Out: os.Stderr}).With().Timestamp().Logger()
+	a.logOutput = os.Stderr
+	a.Logger = zerolog.New(os.Stdout).Output(zerolog.ConsoleWriter{Out: a.logOutput}).With().Timestamp().Logger()
+}
+
+// InitNotifier sets storage events notifier to App structure
+func (a *App) InitNotifier() error {
+	switch a.Config.Notifier.Backend {
+	case "local":
+		a.Notifier = notifier.NewLocal()
+	case "redis":
+		n, err := db.NewRedisNotifier(a.Config.Database.Host, a.Config.Database.Password, a.Config.Database.Port,
+			a.Config.Notifier.Channel, a.Logger)
+		if err != nil {
+			return err
+		}
+		a.Notifier = n
+	default:
+		return fmt.Errorf("unknown notifier backend %q", a.Config.Notifier.Backend)
+	}
+
+	a.Notifier.Subscribe(func(e notifier.Event) {
+		a.Logger.Debug().Str("op", e.Op).Str("id", e.ID).Str("origin", e.Origin).Msg("Storage event")
+	})
+	return nil
 }
 
 // InitStorage sets database to App structure
@@ -36,19 +92,243 @@ func (a *App) InitStorage() error {
 	if err != nil {
 		return err
 	}
+	s.SetLogger(a.Logger)
+	s.SetTimeout(a.Config.Database.Timeout)
+	if a.Notifier != nil {
+		s.SetNotifier(a.Notifier)
+	}
+	a.redis = s
 	a.Storage = s
+	a.Keys = s
+
+	// only calls which reach redis are observed, cache hits are not
+	a.Storage = instrument.New(a.Storage, a.Metrics)
+	if a.Metrics != nil {
+		a.Metrics.RegisterPoolStats(s.Stats)
+		a.Metrics.RegisterHeroCount(s.CountHeroes, time.Minute)
+	}
+
+	if a.Config.Database.Coalesce {
+		a.Storage = coalesce.New(a.Storage)
+	}
+
+	if a.Config.Cache.Size > 0 {
+		c := cache.New(a.Storage, a.Config.Cache.Size, a.Config.Cache.TTL, a.Config.Cache.ListTTL)
+		if a.Notifier != nil {
+			// writes made by other instances invalidate local cache too
+			a.Notifier.Subscribe(func(e notifier.Event) {
+				c.Invalidate(e.ID)
+			})
+		}
+		a.Storage = c
+	}
 	return nil
 }
 
-// Run runs server from App structure
-func (a *App) Run() error {
-	a.Logger.Info().Int("port", a.Config.Server.Port).Msg("Run app")
+// InitRateLimiter sets rate limiter to App structure, limits kept in redis
+// fall back to local ones while redis is unavailable
+func (a *App) InitRateLimiter() error {
+	switch a.Config.RateLimit.Backend {
+	case "local":
+		a.Limiter = ratelimit.NewLocal()
+	case "redis":
+		if a.redis == nil {
+			return errors.New("redis rate limiter requires storage")
+		}
+		a.Limiter = ratelimit.NewFallback(db.NewRateLimiter(a.redis), ratelimit.NewLocal(), a.Logger)
+	default:
+		return fmt.Errorf("unknown rate limiter backend %q", a.Config.RateLimit.Backend)
+	}
+	return nil
+}
 
-	a.Server = server.NewServer(a.Storage, a.Logger, a.Config)
+// InitTracing installs tracer provider with configured exporter, trace
+// context of incoming requests is propagated even without exporter
+func (a *App) InitTracing(ctx context.Context) error {
+	stop, err := tracing.Setup(ctx, tracing.Options{
+		Exporter:    a.Config.Tracing.Exporter,
+		Target:      a.Config.Tracing.Target,
+		SampleRatio: a.Config.Tracing.SampleRatio,
+	})
+	if err != nil {
+		return err
+	}
+	a.stopTracing = stop
+	return nil
+}
 
-	err := a.Server.Run()
+// InitJWT sets verifier of bearer tokens to App structure, it's left
+// un-set when no key file is configured
+func (a *App) InitJWT() error {
+	conf := a.Config.JWT
+	ks := auth.NewKeySet()
+
+	if conf.HMACKeyFile != "" {
+		key, err := auth.LoadHMACKey(conf.HMACKeyFile)
+		if err != nil {
+			return err
+		}
+		ks.Add("", key)
+	}
+	if conf.PublicKeyFile != "" {
+		key, err := auth.LoadPublicKey(conf.PublicKeyFile)
+		if err != nil {
+			return err
+		}
+		ks.Add("", key)
+	}
+	if conf.JWKSFile != "" {
+		if err := auth.LoadJWKS(conf.JWKSFile, ks); err != nil {
+			return err
+		}
+	}
+	if ks.Len() == 0 {
+		return nil
+	}
+
+	v, err := auth.NewJWTVerifier(ks, auth.JWTOptions{
+		Issuer:     conf.Issuer,
+		Audience:   conf.Audience,
+		RolesClaim: conf.RolesClaim,
+		Leeway:     conf.Leeway,
+	})
 	if err != nil {
 		return err
 	}
+	a.JWT = v
 	return nil
 }
+
+// InitServers sets http and gRPC servers to App structure
+func (a *App) InitServers() {
+	srv := server.NewServer(a.Storage, a.Logger, a.Config)
+	srv.Keys = a.Keys
+	srv.JWT = a.JWT
+	if a.Limiter != nil {
+		srv.Limiter = a.Limiter
+	}
+	if a.Metrics != nil {
+		srv.Metrics = a.Metrics
+	}
+	srv.Health = a.healthMonitor()
+	a.Server = srv
+	a.GRPCServer = grpcserver.NewServer(a.Storage, a.Logger, a.Config)
+	a.GRPCServer.Keys = a.Keys
+	a.GRPCServer.JWT = a.JWT
+}
+
+// healthMonitor returns monitor of dependencies checked by readiness probe
+func (a *App) healthMonitor() *health.Monitor {
+	var deps []health.Dependency
+	if a.Storage != nil {
+		deps = append(deps, health.StorageDependency(a.Storage))
+	}
+	if p, ok := a.Notifier.(notifier.Pinger); ok {
+		deps = append(deps, health.Dependency{Name: "notifier", Check: p.Ping})
+	}
+	return health.NewMonitor(a.Config.Server.ReadinessTimeout, deps...)
+}
+
+// SetMaintenance turns maintenance mode of http server on or off, readiness
+// probe fails in maintenance mode so load balancer stops routing requests
+// to this instance
+func (a *App) SetMaintenance(on bool) {
+	a.Logger.Info().Bool("maintenance", on).Msg("Set maintenance mode")
+	if a.Server != nil {
+		a.Server.SetMaintenance(on)
+	}
+}
+
+// Run runs http and gRPC servers from App structure
+// when one of servers stops the other one is stopped too
+func (a *App) Run() error {
+	a.Logger.Info().Int("port", a.Config.Server.Port).Int("grpcport", a.Config.GRPC.Port).Msg("Run app")
+
+	if a.Server == nil || a.GRPCServer == nil {
+		a.InitServers()
+	}
+
+	errCh := make(chan error, 2)
+	go func() { errCh <- a.Server.Run() }()
+	go func() { errCh <- a.GRPCServer.Run() }()
+
+	err := <-errCh
+	// server which failed takes the other one down, while servers stopped
+	// by Shutdown are drained there
+	if !a.shuttingDown.Load() {
+		a.Server.Close()
+		a.GRPCServer.Close()
+	}
+	if err2 := <-errCh; err == nil {
+		err = err2
+	}
+
+	return err
+}
+
+// Shutdown stops application gracefully: readiness is failed first, so load
+// balancer stops routing new requests, after ShutdownDelay servers stop
+// accepting connections and drain in-flight requests until ctx is done,
+// then storage and notifier are closed and logs are flushed
+func (a *App) Shutdown(ctx context.Context) error {
+	a.Logger.Info().Dur("delay", a.Config.Server.ShutdownDelay).Msg("Shutdown app")
+	a.shuttingDown.Store(true)
+
+	if a.Server != nil {
+		a.Server.SetReady(false)
+	}
+
+	select {
+	case <-time.After(a.Config.Server.ShutdownDelay):
+	case <-ctx.Done():
+	}
+
+	var errs []error
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	drain := func(name string, shutdown func(context.Context) error) {
+		defer wg.Done()
+		if err := shutdown(ctx); err != nil {
+			a.Logger.Error().Err(err).Str("server", name).Msg("Unable to drain connections")
+			mu.Lock()
+			errs = append(errs, err)
+			mu.Unlock()
+		}
+	}
+	if a.Server != nil {
+		wg.Add(1)
+		go drain("http", a.Server.Shutdown)
+	}
+	if a.GRPCServer != nil {
+		wg.Add(1)
+		go drain("grpc", a.GRPCServer.Shutdown)
+	}
+	wg.Wait()
+
+	if a.Storage != nil {
+		if err := a.Storage.Close(); err != nil {
+			a.Logger.Error().Err(err).Msg("Unable to close storage")
+			errs = append(errs, err)
+		}
+	}
+	if a.Notifier != nil {
+		if err := a.Notifier.Close(); err != nil {
+			a.Logger.Error().Err(err).Msg("Unable to close notifier")
+			errs = append(errs, err)
+		}
+	}
+
+	if a.stopTracing != nil {
+		if err := a.stopTracing(ctx); err != nil {
+			a.Logger.Error().Err(err).Msg("Unable to flush spans")
+			errs = append(errs, err)
+		}
+	}
+
+	a.Logger.Info().Msg("App stopped")
+	if a.logOutput != nil {
+		a.logOutput.Sync()
+	}
+
+	return errors.Join(errs...)
+}
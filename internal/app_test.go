@@ -0,0 +1,85 @@
+package heroes
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/config"
+	"github.com/bliuchak/heroes/internal/server"
+	"github.com/bliuchak/heroes/internal/storage"
+	stmocks "github.com/bliuchak/heroes/internal/storage/mocks"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// freePort returns port which isn't listened at the moment
+func freePort(t *testing.T) int {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	return lis.Addr().(*net.TCPAddr).Port
+}
+
+func TestApp_ShutdownDrainsRequests(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	st := new(stmocks.Storager)
+	st.On("GetHero", mock.Anything, "1").Return(storage.Hero{ID: "1", Name: "Batman"}, nil).
+		Run(func(mock.Arguments) {
+			close(started)
+			<-release
+		})
+	st.On("Status", mock.Anything).Return("PONG", nil).Maybe()
+	st.On("Close").Return(nil)
+
+	a := NewApplication(config.Config{
+		Server: config.Server{Port: freePort(t)},
+		GRPC:   config.GRPC{Port: freePort(t)},
+	})
+	a.Logger = zerolog.Nop()
+	a.Storage = st
+	a.InitServers()
+
+	addr := "127.0.0.1:" + strconv.Itoa(a.Config.Server.Port)
+	srv := a.Server.(*server.Server)
+
+	run := make(chan error, 1)
+	go func() { run <- a.Run() }()
+	assert.Eventually(t, srv.Ready, time.Second, time.Millisecond)
+
+	codes := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/v1/hero/1")
+		if err != nil {
+			codes <- 0
+			return
+		}
+		resp.Body.Close()
+		codes <- resp.StatusCode
+	}()
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- a.Shutdown(context.Background()) }()
+
+	// both servers stopped serving, but in-flight request is still drained
+	assert.NoError(t, <-run)
+	select {
+	case <-shutdown:
+		t.Fatal("shutdown finished before in-flight request")
+	default:
+	}
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-codes)
+	assert.NoError(t, <-shutdown)
+	st.AssertExpectations(t)
+}
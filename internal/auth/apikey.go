@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/storage"
+)
+
+// API key is sent by client as "<id>.<secret>", id is used to look key up
+// in storage while only hash of secret is stored
+const tokenSeparator = "."
+
+// NewAPIKey generates API key with given name and scopes, returned token
+// is the only place where secret is kept, so it must be shown to client once
+func NewAPIKey(name string, scopes []string) (storage.APIKey, string, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return storage.APIKey{}, "", err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return storage.APIKey{}, "", err
+	}
+
+	key := storage.APIKey{
+		ID:        id,
+		Name:      name,
+		Hash:      HashSecret(secret),
+		Scopes:    scopes,
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	return key, id + tokenSeparator + secret, nil
+}
+
+// ParseToken splits API key sent by client into key ID and secret
+func ParseToken(token string) (id, secret string, ok bool) {
+	id, secret, ok = strings.Cut(token, tokenSeparator)
+	return id, secret, ok && id != "" && secret != ""
+}
+
+// HashSecret returns hex encoded SHA-256 of secret, secrets are random,
+// so slow password hashes are not needed
+func HashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifySecret reports whether secret matches stored key
+func VerifySecret(key storage.APIKey, secret string) bool {
+	return subtle.ConstantTimeCompare([]byte(key.Hash), []byte(HashSecret(secret))) == 1
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
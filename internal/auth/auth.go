@@ -0,0 +1,96 @@
+// Package auth describes who makes request and what it's allowed to do
+package auth
+
+import (
+	"context"
+
+	"github.com/bliuchak/heroes/internal/storage"
+)
+
+// scopes which can be granted to API clients
+const (
+	// ScopeRead allows to read heroes
+	ScopeRead = "heroes:read"
+	// ScopeWrite allows to create, update and delete heroes
+	ScopeWrite = "heroes:write"
+	// ScopeAdmin allows everything including management of API keys
+	ScopeAdmin = "admin"
+)
+
+// Scopes returns all known scopes
+func Scopes() []string {
+	return []string{ScopeRead, ScopeWrite, ScopeAdmin}
+}
+
+// ValidScope reports whether scope is known
+func ValidScope(scope string) bool {
+	for _, s := range Scopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// prefixes of subjects keep clients authenticated by different methods apart
+const (
+	SubjectPrefixAPIKey = "apikey:"
+	SubjectPrefixJWT    = "jwt:"
+)
+
+// Principal is authenticated client of API
+type Principal struct {
+	// Subject identifies client, e.g. "apikey:<id>" or "jwt:<sub>"
+	Subject string
+	// Roles are granted by JWT, API keys are granted scopes directly
+	Roles  []string
+	Scopes []string
+}
+
+// HasScope reports whether principal is granted scope, admin is granted
+// every scope
+func (p Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// Owner returns principal as owner of heroes it writes, admin may write
+// heroes of other owners
+func (p Principal) Owner() storage.Owner {
+	return storage.Owner{ID: p.Subject, Admin: p.HasScope(ScopeAdmin)}
+}
+
+// Anonymous is principal of requests which aren't authenticated because
+// authentication is disabled
+var Anonymous = Principal{Subject: "anonymous", Scopes: []string{ScopeAdmin}}
+
+type principalKey struct{}
+
+// NewContext returns copy of ctx which carries principal
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns principal stored in ctx
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// Allowed reports whether principal stored in ctx is granted scope,
+// unauthenticated requests are not allowed anything
+func Allowed(ctx context.Context, scope string) bool {
+	p, ok := FromContext(ctx)
+	return ok && p.HasScope(scope)
+}
+
+// OwnerOf returns principal stored in ctx as owner of heroes,
+// unauthenticated requests don't own anything
+func OwnerOf(ctx context.Context) storage.Owner {
+	p, _ := FromContext(ctx)
+	return p.Owner()
+}
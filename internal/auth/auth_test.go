@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrincipal_HasScope(t *testing.T) {
+	reader := Principal{Scopes: []string{ScopeRead}}
+	assert.True(t, reader.HasScope(ScopeRead))
+	assert.False(t, reader.HasScope(ScopeWrite))
+	assert.False(t, reader.HasScope(ScopeAdmin))
+
+	admin := Principal{Scopes: []string{ScopeAdmin}}
+	assert.True(t, admin.HasScope(ScopeRead))
+	assert.True(t, admin.HasScope(ScopeWrite))
+}
+
+func TestNewAPIKey(t *testing.T) {
+	key, token, err := NewAPIKey("ci", []string{ScopeRead})
+	assert.NoError(t, err)
+
+	id, secret, ok := ParseToken(token)
+	assert.True(t, ok)
+	assert.Equal(t, key.ID, id)
+	assert.True(t, VerifySecret(key, secret))
+	assert.False(t, VerifySecret(key, secret+"0"))
+
+	_, _, ok = ParseToken("abc")
+	assert.False(t, ok)
+	_, _, ok = ParseToken(".abc")
+	assert.False(t, ok)
+}
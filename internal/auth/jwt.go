@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// roles which JWT may grant, every role maps to scopes
+const (
+	RoleViewer = "viewer"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+var roleScopes = map[string][]string{
+	RoleViewer: {ScopeRead},
+	RoleEditor: {ScopeRead, ScopeWrite},
+	RoleAdmin:  {ScopeAdmin},
+}
+
+// ScopesOf returns scopes granted by roles, unknown roles grant nothing
+func ScopesOf(roles []string) []string {
+	var scopes []string
+	for _, r := range roles {
+		scopes = append(scopes, roleScopes[r]...)
+	}
+	return scopes
+}
+
+// ErrInvalidToken is returned for tokens which can't be trusted
+var ErrInvalidToken = errors.New("token is invalid")
+
+// JWTOptions contains claims checked by JWTVerifier
+type JWTOptions struct {
+	// Issuer and Audience are required to match when set
+	Issuer   string
+	Audience string
+	// RolesClaim is name of claim with roles, nested claims are separated
+	// by dot, e.g. "realm_access.roles"
+	RolesClaim string
+	// Leeway is allowed clock skew of exp, nbf and iat checks
+	Leeway time.Duration
+}
+
+// JWTVerifier verifies bearer tokens signed by keys from KeySet
+type JWTVerifier struct {
+	keys       *KeySet
+	parser     *jwt.Parser
+	rolesClaim string
+}
+
+// NewJWTVerifier returns pointer to JWTVerifier, only methods of keys in
+// set are accepted, so token can't choose how it's verified
+func NewJWTVerifier(keys *KeySet, opts JWTOptions) (*JWTVerifier, error) {
+	if keys.Len() == 0 {
+		return nil, errors.New("no keys to verify tokens")
+	}
+
+	popts := []jwt.ParserOption{
+		jwt.WithValidMethods(keys.Methods()),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(opts.Leeway),
+	}
+	if opts.Issuer != "" {
+		popts = append(popts, jwt.WithIssuer(opts.Issuer))
+	}
+	if opts.Audience != "" {
+		popts = append(popts, jwt.WithAudience(opts.Audience))
+	}
+
+	rolesClaim := opts.RolesClaim
+	if rolesClaim == "" {
+		rolesClaim = "roles"
+	}
+
+	return &JWTVerifier{
+		keys:       keys,
+		parser:     jwt.NewParser(popts...),
+		rolesClaim: rolesClaim,
+	}, nil
+}
+
+// Verify checks signature and claims of token and returns its principal
+// errors wrap ErrInvalidToken and reason, so they may be reported to client
+func (v *JWTVerifier) Verify(token string) (Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := v.keys.Lookup(kid, t.Method.Alg())
+		if !ok {
+			return nil, errors.New("signing key is unknown")
+		}
+		return key, nil
+	})
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	}
+
+	sub, err := claims.GetSubject()
+	if err != nil || sub == "" {
+		return Principal{}, fmt.Errorf("%w: token has no subject", ErrInvalidToken)
+	}
+
+	// subject is prefixed, so token can't impersonate API key or anonymous
+	// client by choosing their subject
+	roles := stringsClaim(claims, v.rolesClaim)
+	return Principal{Subject: SubjectPrefixJWT + sub, Roles: roles, Scopes: ScopesOf(roles)}, nil
+}
+
+// stringsClaim returns claim at dotted path as list of strings, claim
+// may be either array or space separated string
+func stringsClaim(claims map[string]interface{}, path string) []string {
+	parts := strings.Split(path, ".")
+	var v interface{} = claims
+	for _, p := range parts {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[p]
+	}
+
+	switch c := v.(type) {
+	case string:
+		return strings.Fields(c)
+	case []interface{}:
+		var ss []string
+		for _, el := range c {
+			if s, ok := el.(string); ok {
+				ss = append(ss, s)
+			}
+		}
+		return ss
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func b64(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func claims(overrides jwt.MapClaims) jwt.MapClaims {
+	c := jwt.MapClaims{
+		"sub":   "alice",
+		"iss":   "https://id.example.com",
+		"aud":   []string{"heroes", "other"},
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{RoleViewer},
+	}
+	for k, v := range overrides {
+		c[k] = v
+	}
+	return c
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, key interface{}, c jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, c)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	s, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestJWTVerifier_Verify(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("0123456789abcdef0123456789abcdef")
+
+	// RSA key comes from PEM file, EC and HMAC keys from JWKS file
+	der, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemPath := writeFile(t, "rsa.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	jwks, _ := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{"kty": "EC", "kid": "ec1", "use": "sig", "crv": "P-256", "x": b64(ecKey.X), "y": b64(ecKey.Y)},
+		{"kty": "oct", "kid": "hs1", "k": base64.RawURLEncoding.EncodeToString(secret)},
+		{"kty": "RSA", "kid": "enc", "use": "enc", "n": b64(rsaKey.N), "e": "AQAB"},
+	}})
+	jwksPath := writeFile(t, "jwks.json", jwks)
+
+	ks := NewKeySet()
+	pub, err := LoadPublicKey(pemPath)
+	assert.NoError(t, err)
+	ks.Add("", pub)
+	assert.NoError(t, LoadJWKS(jwksPath, ks))
+	assert.Equal(t, 3, ks.Len())
+
+	v, err := NewJWTVerifier(ks, JWTOptions{Issuer: "https://id.example.com", Audience: "heroes"})
+	assert.NoError(t, err)
+
+	otherRSA, _ := rsa.GenerateKey(rand.Reader, 2048)
+
+	tests := []struct {
+		name    string
+		token   string
+		err     error
+		subject string
+		scopes  []string
+	}{
+		{
+			name:    "should accept RS256 token verified by PEM key",
+			token:   sign(t, jwt.SigningMethodRS256, "", rsaKey, claims(nil)),
+			subject: "jwt:alice",
+			scopes:  []string{ScopeRead},
+		},
+		{
+			name:    "should accept ES256 token verified by JWKS key",
+			token:   sign(t, jwt.SigningMethodES256, "ec1", ecKey, claims(jwt.MapClaims{"roles": "viewer editor"})),
+			subject: "jwt:alice",
+			scopes:  []string{ScopeRead, ScopeRead, ScopeWrite},
+		},
+		{
+			name:    "should accept HS256 token verified by JWKS key",
+			token:   sign(t, jwt.SigningMethodHS256, "hs1", secret, claims(jwt.MapClaims{"roles": []string{RoleAdmin, "unknown"}})),
+			subject: "jwt:alice",
+			scopes:  []string{ScopeAdmin},
+		},
+		{
+			name:    "should not collide with API key subject",
+			token:   sign(t, jwt.SigningMethodRS256, "", rsaKey, claims(jwt.MapClaims{"sub": "apikey:1a2b"})),
+			subject: "jwt:apikey:1a2b",
+			scopes:  []string{ScopeRead},
+		},
+		{
+			name:  "should reject token signed by unknown key",
+			token: sign(t, jwt.SigningMethodRS256, "", otherRSA, claims(nil)),
+			err:   jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			name:  "should reject HS256 token signed by RSA public key",
+			token: sign(t, jwt.SigningMethodHS256, "", der, claims(nil)),
+			err:   ErrInvalidToken,
+		},
+		{
+			name:  "should reject token with unsupported method",
+			token: sign(t, jwt.SigningMethodHS512, "hs1", secret, claims(nil)),
+			err:   jwt.ErrTokenSignatureInvalid,
+		},
+		{
+			name:  "should reject expired token",
+			token: sign(t, jwt.SigningMethodRS256, "", rsaKey, claims(jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()})),
+			err:   jwt.ErrTokenExpired,
+		},
+		{
+			name: "should reject token without expiry",
+			token: sign(t, jwt.SigningMethodRS256, "", rsaKey, func() jwt.MapClaims {
+				c := claims(nil)
+				delete(c, "exp")
+				return c
+			}()),
+			err: jwt.ErrTokenRequiredClaimMissing,
+		},
+		{
+			name:  "should reject token of other issuer",
+			token: sign(t, jwt.SigningMethodRS256, "", rsaKey, claims(jwt.MapClaims{"iss": "https://evil.example.com"})),
+			err:   jwt.ErrTokenInvalidIssuer,
+		},
+		{
+			name:  "should reject token for other audience",
+			token: sign(t, jwt.SigningMethodRS256, "", rsaKey, claims(jwt.MapClaims{"aud": "billing"})),
+			err:   jwt.ErrTokenInvalidAudience,
+		},
+		{
+			name:  "should reject token without subject",
+			token: sign(t, jwt.SigningMethodRS256, "", rsaKey, claims(jwt.MapClaims{"sub": ""})),
+			err:   ErrInvalidToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := v.Verify(tt.token)
+			if tt.err != nil {
+				assert.True(t, errors.Is(err, tt.err), "unexpected error %v", err)
+				assert.True(t, errors.Is(err, ErrInvalidToken))
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.subject, p.Subject)
+			assert.Equal(t, tt.scopes, p.Scopes)
+		})
+	}
+}
+
+func TestJWTVerifier_NestedRolesClaim(t *testing.T) {
+	secret := []byte("secret")
+	ks := NewKeySet()
+	ks.Add("", secret)
+
+	v, err := NewJWTVerifier(ks, JWTOptions{RolesClaim: "realm_access.roles"})
+	assert.NoError(t, err)
+
+	p, err := v.Verify(sign(t, jwt.SigningMethodHS256, "", secret, claims(jwt.MapClaims{
+		"realm_access": map[string]interface{}{"roles": []string{RoleEditor}},
+	})))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{RoleEditor}, p.Roles)
+	assert.True(t, p.HasScope(ScopeWrite))
+}
+
+func TestNewJWTVerifier_NoKeys(t *testing.T) {
+	_, err := NewJWTVerifier(NewKeySet(), JWTOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,245 @@
+package auth
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+)
+
+// KeySet contains keys which verify JWT signatures
+type KeySet struct {
+	keys []setKey
+}
+
+type setKey struct {
+	kid string
+	key interface{}
+}
+
+// NewKeySet returns pointer to empty KeySet
+func NewKeySet() *KeySet {
+	return &KeySet{}
+}
+
+// Add adds key with given ID (may be empty), key is either []byte (HS256),
+// *rsa.PublicKey (RS256) or *ecdsa.PublicKey (ES256)
+func (ks *KeySet) Add(kid string, key interface{}) {
+	ks.keys = append(ks.keys, setKey{kid: kid, key: key})
+}
+
+// Len returns number of keys in set
+func (ks *KeySet) Len() int {
+	return len(ks.keys)
+}
+
+// Lookup returns key for token signed by method alg with "kid" header,
+// keys without ID verify tokens which "kid" doesn't match any key
+func (ks *KeySet) Lookup(kid, alg string) (interface{}, bool) {
+	if kid != "" {
+		for _, k := range ks.keys {
+			if k.kid == kid && methodOf(k.key) == alg {
+				return k.key, true
+			}
+		}
+	}
+	for _, k := range ks.keys {
+		if k.kid == "" && methodOf(k.key) == alg {
+			return k.key, true
+		}
+	}
+	return nil, false
+}
+
+// Methods returns signing methods of keys in set
+func (ks *KeySet) Methods() []string {
+	seen := map[string]bool{}
+	var methods []string
+	for _, k := range ks.keys {
+		m := methodOf(k.key)
+		if m != "" && !seen[m] {
+			seen[m] = true
+			methods = append(methods, m)
+		}
+	}
+	return methods
+}
+
+func methodOf(key interface{}) string {
+	switch key.(type) {
+	case []byte:
+		return "HS256"
+	case *rsa.PublicKey:
+		return "RS256"
+	case *ecdsa.PublicKey:
+		return "ES256"
+	}
+	return ""
+}
+
+// LoadHMACKey reads HS256 secret from file
+func LoadHMACKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%s: HMAC secret is empty", path)
+	}
+	return data, nil
+}
+
+// LoadPublicKey reads PEM encoded RSA or ECDSA P-256 public key from file
+func LoadPublicKey(path string) (interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s: no PEM block found", path)
+	}
+
+	var key interface{}
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "CERTIFICATE":
+		var cert *x509.Certificate
+		cert, err = x509.ParseCertificate(block.Bytes)
+		if err == nil {
+			key = cert.PublicKey
+		}
+	default:
+		return nil, fmt.Errorf("%s: unsupported PEM block %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if ec, ok := key.(*ecdsa.PublicKey); ok && ec.Curve != elliptic.P256() {
+		return nil, fmt.Errorf("%s: only P-256 curve is supported", path)
+	}
+	if methodOf(key) == "" {
+		return nil, fmt.Errorf("%s: unsupported key type %T", path, key)
+	}
+	return key, nil
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	// RSA
+	N string `json:"n"`
+	E string `json:"e"`
+	// EC
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	// symmetric
+	K string `json:"k"`
+}
+
+// LoadJWKS reads JSON Web Key Set (RFC 7517) from file and adds its keys
+// to ks, keys which are not meant for signatures are skipped
+func LoadJWKS(path string, ks *KeySet) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return fmt.Errorf("%s: key %d: %w", path, i, err)
+		}
+		ks.Add(k.Kid, key)
+	}
+	return nil
+}
+
+func (k jwk) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() {
+			return nil, errors.New("RSA exponent is too big")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if x.BitLen() > 256 || y.BitLen() > 256 {
+			return nil, errors.New("EC coordinate is too long")
+		}
+		// ecdh rejects points which are not on curve
+		point := make([]byte, 65)
+		point[0] = 4
+		x.FillBytes(point[1:33])
+		y.FillBytes(point[33:])
+		if _, err := ecdh.P256().NewPublicKey(point); err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, nil
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return nil, err
+		}
+		if len(secret) == 0 {
+			return nil, errors.New("HMAC secret is empty")
+		}
+		return secret, nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("empty key parameter")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
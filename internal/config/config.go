@@ -1,9 +1,25 @@
 package config
 
+import (
+	"net/netip"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/ratelimit"
+)
+
 // Config contains application config data
 type Config struct {
-	Database Database
-	Server   Server
+	Database  Database
+	Server    Server
+	GRPC      GRPC
+	Notifier  Notifier
+	Cache     Cache
+	Auth      Auth
+	JWT       JWT
+	RateLimit RateLimit
+	CORS      CORS
+	AccessLog AccessLog
+	Tracing   Tracing
 }
 
 // Database contains database config data
@@ -11,11 +27,127 @@ type Database struct {
 	Host     string
 	Port     string
 	Password string
+	// Coalesce lets concurrent identical reads share single storage call
+	Coalesce bool
+	// Timeout limits every storage call, 0 means that only deadline
+	// of request is used
+	Timeout time.Duration
 }
 
 // Server contains server config data
 type Server struct {
 	Port int
+	// LegacyDeprecatedAt and LegacySunset are announced on unversioned routes
+	LegacyDeprecatedAt time.Time
+	LegacySunset       time.Time
+	// ShutdownDelay is how long server keeps serving after readiness is
+	// failed, so load balancer notices it before connections are closed
+	ShutdownDelay time.Duration
+	// ShutdownGrace limits how long in-flight requests are drained
+	ShutdownGrace time.Duration
+	// ReadinessTimeout limits check of every dependency in readiness probe
+	ReadinessTimeout time.Duration
+	// Debug sends stack trace of panic in response, for local development only
+	Debug bool
+}
+
+// GRPC contains gRPC server config data
+type GRPC struct {
+	Port int
+}
+
+// Notifier contains storage events notifier config data
+type Notifier struct {
+	// Backend is either "redis" to share events between instances
+	// or "local" for single-node setup
+	Backend string
+	Channel string
+}
+
+// Cache contains storage cache config data
+type Cache struct {
+	// Size is max number of cached heroes, 0 disables cache
+	Size    int
+	TTL     time.Duration
+	ListTTL time.Duration
+}
+
+// Auth contains API authentication config data
+type Auth struct {
+	// Enabled requires API key on hero and admin routes
+	Enabled bool
+	// AdminKey is accepted with admin scope, it's used to create the first
+	// API keys, empty value disables it
+	AdminKey string
+}
+
+// JWT contains bearer token verification config data, tokens are accepted
+// when at least one key file is set
+type JWT struct {
+	// HMACKeyFile contains HS256 secret
+	HMACKeyFile string
+	// PublicKeyFile contains PEM encoded RSA (RS256) or P-256 (ES256) key
+	PublicKeyFile string
+	// JWKSFile contains JSON Web Key Set
+	JWKSFile string
+	Issuer   string
+	Audience string
+	// RolesClaim is name of claim with roles, nested claims are separated by dot
+	RolesClaim string
+	// Leeway is allowed clock skew
+	Leeway time.Duration
+}
+
+// RateLimit contains request rate limiting config data, zero limits
+// disable it
+type RateLimit struct {
+	// Backend is either "redis" to share limits between instances
+	// or "local" for single-node setup
+	Backend string
+	// Default applies to every client on every route
+	Default ratelimit.Limit
+	// Routes override Default, keys are method and route, e.g. "GET /v1/heroes"
+	Routes map[string]ratelimit.Limit
+	// Clients override limits of routes, keys are client IDs,
+	// e.g. "apikey:1a2b" or "ip:10.0.0.1"
+	Clients map[string]ratelimit.Limit
+	// IP limits all requests from single IP before they are authenticated,
+	// so invalid credentials are limited too
+	IP ratelimit.Limit
+}
+
+// CORS contains cross-origin requests config data, CORS is disabled
+// without Origins
+type CORS struct {
+	// Origins may have wildcard subdomain, e.g. "https://*.example.com",
+	// "*" allows any origin
+	Origins     []string
+	Methods     []string
+	Headers     []string
+	Credentials bool
+	// MaxAge is how long browser may cache preflight response
+	MaxAge time.Duration
+}
+
+// AccessLog contains access log config data
+type AccessLog struct {
+	// Sample logs only every n-th successful request, failed requests are
+	// always logged, 0 logs every request
+	Sample int
+	// TrustedProxies may set X-Forwarded-For header with address of client
+	TrustedProxies []netip.Prefix
+}
+
+// Tracing contains tracing config data
+type Tracing struct {
+	// Exporter is one of tracing.Exporters(), e.g. "stdout", "file" or
+	// "otlp", empty value disables export of spans
+	Exporter string
+	// Target is path of file for "file" exporter or URL of collector for
+	// "otlp" exporter, e.g. "http://localhost:4318"
+	Target string
+	// SampleRatio is share of new traces which are sampled
+	SampleRatio float64
 }
 
 // NewConfig returns pointer on Config with filled data
@@ -0,0 +1,99 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/storage"
+	"github.com/mediocregopher/radix/v3"
+)
+
+const apiKeyPrefix = "apikey"
+
+// CreateAPIKey stores API key as hash
+func (r *Redis) CreateAPIKey(ctx context.Context, key storage.APIKey) error {
+	err := r.do(ctx, "CreateAPIKey", radix.Cmd(nil, "HSET", apiKeyPrefix+"."+key.ID,
+		"name", key.Name,
+		"hash", key.Hash,
+		"scopes", strings.Join(key.Scopes, " "),
+		"created_at", key.CreatedAt.UTC().Format(time.RFC3339),
+	))
+	if err != nil {
+		return wrapErr("CreateAPIKey", key.ID, err)
+	}
+	return nil
+}
+
+// GetAPIKey gets API key by ID
+func (r *Redis) GetAPIKey(ctx context.Context, id string) (storage.APIKey, error) {
+	var fields map[string]string
+	if err := r.do(ctx, "GetAPIKey", radix.Cmd(&fields, "HGETALL", apiKeyPrefix+"."+id)); err != nil {
+		return storage.APIKey{}, wrapErr("GetAPIKey", id, err)
+	}
+
+	// HGETALL replies empty list for missing key
+	if len(fields) == 0 {
+		return storage.APIKey{}, storage.NewError(storage.ErrNotFound, "GetAPIKey", id, nil)
+	}
+
+	return apiKeyFromFields(id, fields), nil
+}
+
+// GetAPIKeys gets all API keys
+func (r *Redis) GetAPIKeys(ctx context.Context) ([]storage.APIKey, error) {
+	keys := []storage.APIKey{}
+
+	opts := radix.ScanOpts{
+		Command: "SCAN",
+		Pattern: apiKeyPrefix + ".*",
+		Count:   100,
+	}
+	scanner := radix.NewScanner(ctxClient{r: r, ctx: ctx, op: "GetAPIKeys"}, opts)
+
+	var name string
+	for scanner.Next(&name) {
+		id := strings.TrimPrefix(name, apiKeyPrefix+".")
+		var fields map[string]string
+		if err := r.do(ctx, "GetAPIKeys", radix.Cmd(&fields, "HGETALL", name)); err != nil {
+			return []storage.APIKey{}, wrapErr("GetAPIKeys", id, err)
+		}
+		// key was revoked during scan
+		if len(fields) == 0 {
+			continue
+		}
+		keys = append(keys, apiKeyFromFields(id, fields))
+	}
+
+	if err := scanner.Close(); err != nil {
+		return []storage.APIKey{}, wrapErr("GetAPIKeys", "", err)
+	}
+
+	return keys, nil
+}
+
+// DeleteAPIKey deletes API key by ID
+// returns storage.ErrNotFound when key doesn't exist
+func (r *Redis) DeleteAPIKey(ctx context.Context, id string) error {
+	var deleted int
+	if err := r.do(ctx, "DeleteAPIKey", radix.Cmd(&deleted, "DEL", apiKeyPrefix+"."+id)); err != nil {
+		return wrapErr("DeleteAPIKey", id, err)
+	}
+
+	if deleted == 0 {
+		return storage.NewError(storage.ErrNotFound, "DeleteAPIKey", id, nil)
+	}
+	return nil
+}
+
+func apiKeyFromFields(id string, fields map[string]string) storage.APIKey {
+	// malformed date is left zero, key is still usable
+	createdAt, _ := time.Parse(time.RFC3339, fields["created_at"])
+	return storage.APIKey{
+		ID:        id,
+		Name:      fields["name"],
+		Hash:      fields["hash"],
+		Scopes:    strings.Fields(fields["scopes"]),
+		CreatedAt: createdAt,
+	}
+}
@@ -0,0 +1,80 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/storage"
+	"github.com/mediocregopher/radix/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+// hashStub returns radix.Stub which keeps redis hashes in memory
+func hashStub() radix.Client {
+	hashes := map[string]map[string]string{}
+	return radix.Stub("", "", func(args []string) interface{} {
+		switch args[0] {
+		case "HSET":
+			h := map[string]string{}
+			for i := 2; i+1 < len(args); i += 2 {
+				h[args[i]] = args[i+1]
+			}
+			hashes[args[1]] = h
+			return len(h)
+		case "HGETALL":
+			h, ok := hashes[args[1]]
+			if !ok {
+				return []string{}
+			}
+			return h
+		case "DEL":
+			if _, ok := hashes[args[1]]; !ok {
+				return 0
+			}
+			delete(hashes, args[1])
+			return 1
+		case "SCAN":
+			keys := []string{}
+			for k := range hashes {
+				keys = append(keys, k)
+			}
+			return []interface{}{"0", keys}
+		default:
+			return fmt.Errorf("testStub doesn't support command %q", args[0])
+		}
+	})
+}
+
+func TestDbRedis_APIKeys(t *testing.T) {
+	r := Redis{client: hashStub()}
+	ctx := context.Background()
+
+	key := storage.APIKey{
+		ID:        "abc",
+		Name:      "ci",
+		Hash:      "deadbeef",
+		Scopes:    []string{"heroes:read", "heroes:write"},
+		CreatedAt: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+	}
+	assert.NoError(t, r.CreateAPIKey(ctx, key))
+
+	got, err := r.GetAPIKey(ctx, "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, key, got)
+
+	keys, err := r.GetAPIKeys(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.APIKey{key}, keys)
+
+	assert.NoError(t, r.DeleteAPIKey(ctx, "abc"))
+
+	_, err = r.GetAPIKey(ctx, "abc")
+	assert.Equal(t, storage.NewError(storage.ErrNotFound, "GetAPIKey", "abc", nil), err)
+	assert.Equal(t, storage.NewError(storage.ErrNotFound, "DeleteAPIKey", "abc", nil), r.DeleteAPIKey(ctx, "abc"))
+
+	keys, err = r.GetAPIKeys(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+
+	"github.com/bliuchak/heroes/internal/storage"
+	"github.com/mediocregopher/radix/v3/resp/resp2"
+)
+
+// redis error replies which tell that server can't serve requests right now
+var unavailablePrefixes = []string{"LOADING", "BUSY", "MASTERDOWN", "TRYAGAIN", "CLUSTERDOWN"}
+
+// wrapErr wraps radix error into storage.Error of matching kind
+func wrapErr(op, id string, err error) error {
+	return storage.NewError(errKind(err), op, id, err)
+}
+
+func errKind(err error) storage.Kind {
+	var respErr resp2.Error
+	if errors.As(err, &respErr) {
+		msg := respErr.Error()
+		if strings.HasPrefix(msg, "WRONGTYPE") {
+			return storage.ErrConflict
+		}
+		for _, prefix := range unavailablePrefixes {
+			if strings.HasPrefix(msg, prefix) {
+				return storage.ErrUnavailable
+			}
+		}
+		return storage.ErrInternal
+	}
+
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.Canceled),
+		errors.Is(err, context.DeadlineExceeded),
+		errors.As(err, &netErr),
+		errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.Is(err, syscall.ECONNREFUSED):
+		return storage.ErrUnavailable
+	}
+
+	return storage.ErrInternal
+}
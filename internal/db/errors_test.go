@@ -0,0 +1,36 @@
+package db
+
+import (
+	"errors"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+
+	"github.com/bliuchak/heroes/internal/storage"
+	"github.com/mediocregopher/radix/v3/resp/resp2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapErr(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected storage.Kind
+	}{
+		{name: "should map wrong type reply to conflict", err: resp2.Error{E: errors.New("WRONGTYPE Operation against a key holding the wrong kind of value")}, expected: storage.ErrConflict},
+		{name: "should map loading reply to unavailable", err: resp2.Error{E: errors.New("LOADING Redis is loading the dataset in memory")}, expected: storage.ErrUnavailable},
+		{name: "should map other replies to internal", err: resp2.Error{E: errors.New("ERR unknown command")}, expected: storage.ErrInternal},
+		{name: "should map network error to unavailable", err: &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, expected: storage.ErrUnavailable},
+		{name: "should map closed connection to unavailable", err: io.EOF, expected: storage.ErrUnavailable},
+		{name: "should map unknown error to internal", err: errors.New("boom"), expected: storage.ErrInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := wrapErr("GetHero", "1", tt.err)
+
+			assert.True(t, errors.Is(err, tt.expected))
+			assert.True(t, errors.Is(err, tt.err))
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package db
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+
+	"github.com/bliuchak/heroes/internal/notifier"
+	"github.com/mediocregopher/radix/v3"
+	"github.com/rs/zerolog"
+)
+
+// RedisNotifier publishes storage events to redis channel and delivers
+// events received from that channel to local subscribers, so every instance
+// connected to the same redis sees writes made by others
+type RedisNotifier struct {
+	client  radix.Client
+	pubsub  radix.PubSubConn
+	channel string
+	origin  string
+	logger  zerolog.Logger
+	local   *notifier.Local
+	msgCh   chan radix.PubSubMessage
+	// done stops delivery of received events on Close
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewRedisNotifier returns pointer to RedisNotifier subscribed to given channel
+func NewRedisNotifier(host, password, port, channel string, logger zerolog.Logger) (*RedisNotifier, error) {
+	addr := host + ":" + port
+
+	pool, err := radix.NewPool("tcp", addr, 2, radix.PoolConnFunc(connFunc(password)))
+	if err != nil {
+		return nil, err
+	}
+
+	origin, err := newOrigin()
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+
+	n := &RedisNotifier{
+		client:  pool,
+		pubsub:  radix.PersistentPubSub("tcp", addr, connFunc(password)),
+		channel: channel,
+		origin:  origin,
+		logger:  logger,
+		local:   notifier.NewLocal(),
+		msgCh:   make(chan radix.PubSubMessage, 100),
+		done:    make(chan struct{}),
+	}
+
+	if err := n.pubsub.Subscribe(n.msgCh, channel); err != nil {
+		n.Close()
+		return nil, err
+	}
+	go n.spin()
+
+	return n, nil
+}
+
+// Origin returns unique identifier of this instance
+func (n *RedisNotifier) Origin() string {
+	return n.origin
+}
+
+// Publish sends event to redis channel
+// failures are only logged because the write itself already succeeded
+func (n *RedisNotifier) Publish(e notifier.Event) {
+	e.Origin = n.origin
+	data, err := json.Marshal(e)
+	if err != nil {
+		n.logger.Error().Err(err).Msg("Unable to marshal storage event")
+		return
+	}
+
+	if err := n.client.Do(radix.Cmd(nil, "PUBLISH", n.channel, string(data))); err != nil {
+		n.logger.Error().Err(err).Str("op", e.Op).Str("id", e.ID).Msg("Unable to publish storage event")
+	}
+}
+
+// Ping checks connection to redis, it returns when ctx is done even if
+// redis doesn't answer
+func (n *RedisNotifier) Ping(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- n.client.Do(radix.Cmd(nil, "PING"))
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Subscribe adds handler which will be called for every event in channel
+// including events published by this instance
+func (n *RedisNotifier) Subscribe(handler func(notifier.Event)) {
+	n.local.Subscribe(handler)
+}
+
+// Close unsubscribes from channel, stops delivery of events and closes
+// connections
+func (n *RedisNotifier) Close() error {
+	n.closeOnce.Do(func() {
+		if n.done != nil {
+			close(n.done)
+		}
+	})
+	n.local.Close()
+	if n.pubsub != nil {
+		n.pubsub.Close()
+	}
+	return n.client.Close()
+}
+
+// spin delivers received events until notifier is closed, msgCh isn't
+// closed because pubsub connection may still write to it
+func (n *RedisNotifier) spin() {
+	for {
+		select {
+		case msg := <-n.msgCh:
+			n.dispatch(msg)
+		case <-n.done:
+			return
+		}
+	}
+}
+
+func (n *RedisNotifier) dispatch(msg radix.PubSubMessage) {
+	var e notifier.Event
+	if err := json.Unmarshal(msg.Message, &e); err != nil {
+		n.logger.Error().Err(err).Str("channel", msg.Channel).Msg("Unable to unmarshal storage event")
+		return
+	}
+	n.local.Publish(e)
+}
+
+func newOrigin() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
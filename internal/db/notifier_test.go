@@ -0,0 +1,107 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/notifier"
+	"github.com/mediocregopher/radix/v3"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDbRedisNotifier_Publish(t *testing.T) {
+	var published []string
+	n := RedisNotifier{
+		client: radix.Stub("", "", func(args []string) interface{} {
+			switch args[0] {
+			case "PUBLISH":
+				published = append(published, args[1], args[2])
+				return 1
+			default:
+				return fmt.Errorf("testStub doesn't support command %q", args[0])
+			}
+		}),
+		channel: "heroes.events",
+		origin:  "abc",
+		logger:  zerolog.Nop(),
+		local:   notifier.NewLocal(),
+	}
+
+	n.Publish(notifier.Event{Op: notifier.OpCreate, ID: "1"})
+
+	assert.Equal(t, []string{"heroes.events", `{"op":"create","id":"1","origin":"abc"}`}, published)
+}
+
+func TestDbRedisNotifier_PublishError(t *testing.T) {
+	n := RedisNotifier{
+		client: radix.Stub("", "", func(args []string) interface{} {
+			return errors.New("PUBLISH error")
+		}),
+		logger: zerolog.Nop(),
+		local:  notifier.NewLocal(),
+	}
+
+	assert.NotPanics(t, func() {
+		n.Publish(notifier.Event{Op: notifier.OpDelete, ID: "1"})
+	})
+}
+
+func TestDbRedisNotifier_Dispatch(t *testing.T) {
+	n := RedisNotifier{
+		logger: zerolog.Nop(),
+		local:  notifier.NewLocal(),
+	}
+
+	var received []notifier.Event
+	n.Subscribe(func(e notifier.Event) { received = append(received, e) })
+
+	n.dispatch(radix.PubSubMessage{Message: []byte(`{"op":"delete","id":"2","origin":"xyz"}`)})
+	n.dispatch(radix.PubSubMessage{Message: []byte(`not json`)})
+
+	assert.Equal(t, []notifier.Event{{Op: notifier.OpDelete, ID: "2", Origin: "xyz"}}, received)
+}
+
+func TestDbRedisNotifier_Ping(t *testing.T) {
+	var fail bool
+	n := RedisNotifier{
+		client: radix.Stub("", "", func(args []string) interface{} {
+			if fail {
+				return errors.New("PING error")
+			}
+			return "PONG"
+		}),
+	}
+
+	assert.NoError(t, n.Ping(context.Background()))
+
+	fail = true
+	assert.Error(t, n.Ping(context.Background()))
+}
+
+func TestDbRedisNotifier_CloseStopsDelivery(t *testing.T) {
+	n := &RedisNotifier{
+		client: radix.Stub("", "", func(args []string) interface{} { return nil }),
+		logger: zerolog.Nop(),
+		local:  notifier.NewLocal(),
+		msgCh:  make(chan radix.PubSubMessage, 1),
+		done:   make(chan struct{}),
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		n.spin()
+		close(stopped)
+	}()
+
+	assert.NoError(t, n.Close())
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("spin didn't stop on Close")
+	}
+	assert.NotPanics(t, func() { n.Close() })
+}
@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/bliuchak/heroes/internal/ratelimit"
+	"github.com/mediocregopher/radix/v3"
+)
+
+const rateLimitPrefix = "ratelimit"
+
+// tokenBucketScript takes token from bucket kept as hash of tokens and
+// time of last request, time of redis is used so clocks of instances don't
+// matter. Bucket expires once it would be full again.
+// KEYS: bucket; ARGV: requests, window in milliseconds
+// replies allowed flag (0 or 1) and tokens left
+var tokenBucketScript = radix.NewEvalScript(1, `
+if redis.replicate_commands then
+	redis.replicate_commands()
+end
+local capacity = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000 + math.floor(tonumber(time[2]) / 1000)
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1]) or capacity
+local ts = tonumber(bucket[2]) or now
+if now > ts then
+	tokens = math.min(capacity, tokens + (now - ts) * capacity / window)
+end
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(math.max(now, ts)))
+redis.call('PEXPIRE', KEYS[1], window)
+return {tostring(allowed), tostring(tokens)}
+`)
+
+// RateLimiter keeps token buckets in redis, so limits are shared by every
+// instance connected to the same redis
+type RateLimiter struct {
+	r *Redis
+}
+
+// NewRateLimiter returns pointer to RateLimiter which uses connections of r
+func NewRateLimiter(r *Redis) *RateLimiter {
+	return &RateLimiter{r: r}
+}
+
+// Allow takes token from bucket of key
+func (rl *RateLimiter) Allow(ctx context.Context, key string, l ratelimit.Limit) (ratelimit.Result, error) {
+	var reply []string
+	action := tokenBucketScript.Cmd(&reply, rateLimitPrefix+"."+key,
+		strconv.Itoa(l.Requests), strconv.FormatInt(l.Window.Milliseconds(), 10))
+	if err := rl.r.do(ctx, "RateLimit", action); err != nil {
+		return ratelimit.Result{}, wrapErr("RateLimit", "", err)
+	}
+
+	if len(reply) != 2 {
+		return ratelimit.Result{}, wrapErr("RateLimit", "", fmt.Errorf("unexpected reply %q", reply))
+	}
+	tokens, err := strconv.ParseFloat(reply[1], 64)
+	if err != nil {
+		return ratelimit.Result{}, wrapErr("RateLimit", "", err)
+	}
+
+	return ratelimit.NewResult(reply[0] == "1", tokens, l), nil
+}
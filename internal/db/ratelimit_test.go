@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/ratelimit"
+	"github.com/bliuchak/heroes/internal/storage"
+	"github.com/mediocregopher/radix/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+type rateLimitExpected struct {
+	isError bool
+	error   error
+	result  ratelimit.Result
+}
+
+func TestRateLimiter_Allow(t *testing.T) {
+	tests := []struct {
+		name     string
+		reply    interface{}
+		expected rateLimitExpected
+	}{
+		{
+			name:  "should return error on EVALSHA command",
+			reply: errors.New("EVALSHA error"),
+			expected: rateLimitExpected{
+				isError: true,
+				error:   storage.NewError(storage.ErrInternal, "RateLimit", "", errors.New("EVALSHA error")),
+			},
+		},
+		{
+			name:  "should return error on unexpected reply",
+			reply: []string{"1"},
+			expected: rateLimitExpected{
+				isError: true,
+				error:   storage.NewError(storage.ErrInternal, "RateLimit", "", fmt.Errorf("unexpected reply %q", []string{"1"})),
+			},
+		},
+		{
+			name:  "should allow request",
+			reply: []string{"1", "9.5"},
+			expected: rateLimitExpected{
+				result: ratelimit.Result{Allowed: true, Limit: 10, Remaining: 9, Reset: 3 * time.Second},
+			},
+		},
+		{
+			name:  "should limit request",
+			reply: []string{"0", "0.5"},
+			expected: rateLimitExpected{
+				result: ratelimit.Result{Allowed: false, Limit: 10, Remaining: 0, Reset: 57 * time.Second, RetryAfter: 3 * time.Second},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args []string
+			stub := radix.Stub("", "", func(a []string) interface{} {
+				if a[0] != "EVALSHA" {
+					return fmt.Errorf("testStub doesn't support command %q", a[0])
+				}
+				args = a[3:]
+				return tt.reply
+			})
+
+			rl := NewRateLimiter(&Redis{client: stub})
+			res, err := rl.Allow(context.Background(), "GET /v1/heroes|apikey:1", ratelimit.Limit{Requests: 10, Window: time.Minute})
+
+			if tt.expected.isError {
+				assert.Equal(t, tt.expected.error, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected.result, res)
+			assert.Equal(t, []string{"ratelimit.GET /v1/heroes|apikey:1", "10", "60000"}, args)
+		})
+	}
+}
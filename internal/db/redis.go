@@ -1,94 +1,484 @@
 package db
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/bliuchak/heroes/internal/notifier"
 	"github.com/bliuchak/heroes/internal/storage"
+	"github.com/bliuchak/heroes/internal/tracing"
 	"github.com/mediocregopher/radix/v3"
+	"github.com/mediocregopher/radix/v3/resp"
+	"github.com/mediocregopher/radix/v3/resp/resp2"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
 	heroPrefix = "hero"
+	// ownerPrefix keys keep owner of hero, ownedPrefix sets keep IDs of
+	// heroes created by owner
+	ownerPrefix = "heroowner"
+	ownedPrefix = "ownedheroes"
+	poolSize    = 10
 )
 
 // Redis contains client which operates with storage
 type Redis struct {
-	client radix.Client
+	client   radix.Client
+	notifier notifier.Notifier
+	logger   zerolog.Logger
+	// timeout limits every command which context has no earlier deadline
+	timeout time.Duration
 }
 
 // NewRedis returns pointer to Redis structure with filled data
 func NewRedis(host, password, port string) (*Redis, error) {
-	pool, err := radix.NewPool("tcp", host+":"+port, 10)
+	pool, err := radix.NewPool("tcp", host+":"+port, poolSize, radix.PoolConnFunc(connFunc(password)))
 	if err != nil {
-		return nil, err
+		return nil, wrapErr("Connect", "", err)
 	}
 
-	return &Redis{client: pool}, nil
+	return &Redis{client: pool, logger: zerolog.Nop()}, nil
+}
+
+// SetLogger sets logger
+func (r *Redis) SetLogger(logger zerolog.Logger) {
+	r.logger = logger
+}
+
+// SetTimeout sets default deadline of single command, 0 means that only
+// deadline of request context is used
+func (r *Redis) SetTimeout(timeout time.Duration) {
+	r.timeout = timeout
+}
+
+// SetNotifier sets notifier which receives event for every successful write
+func (r *Redis) SetNotifier(n notifier.Notifier) {
+	r.notifier = n
 }
 
 // Status checks storage connection status
-func (r *Redis) Status() (string, error) {
+func (r *Redis) Status(ctx context.Context) (string, error) {
 	var status string
-	if err := r.client.Do(radix.Cmd(&status, "PING")); err != nil {
-		return status, err
+	if err := r.do(ctx, "Status", radix.Cmd(&status, "PING")); err != nil {
+		return status, wrapErr("Status", "", err)
 	}
 
 	return status, nil
 }
 
 // GetHeroes gets all heroes
-func (r *Redis) GetHeroes() ([]storage.Hero, error) {
-	var heroes []storage.Hero
+func (r *Redis) GetHeroes(ctx context.Context) ([]storage.Hero, error) {
+	var ids []string
 
 	opts := radix.ScanOpts{
 		Command: "SCAN",
 		Pattern: heroPrefix + ".*",
 		Count:   100,
 	}
-	scanner := radix.NewScanner(r.client, opts)
+	scanner := radix.NewScanner(ctxClient{r: r, ctx: ctx, op: "GetHeroes"}, opts)
 
 	var key string
 	for scanner.Next(&key) {
-		id := strings.Split(key, ".")
-		var name string
-		if err := r.client.Do(radix.Cmd(&name, "GET", key)); err != nil {
-			return []storage.Hero{}, err
-		}
-		heroes = append(heroes, storage.Hero{ID: id[1], Name: name})
+		ids = append(ids, strings.TrimPrefix(key, heroPrefix+"."))
 	}
 
 	if err := scanner.Close(); err != nil {
-		return []storage.Hero{}, err
+		return []storage.Hero{}, wrapErr("GetHeroes", "", err)
 	}
 
+	// heroes deleted during scan are omitted
+	heroes, err := r.getHeroes(ctx, "GetHeroes", ids)
+	if err != nil {
+		return []storage.Hero{}, wrapErr("GetHeroes", "", err)
+	}
+	if len(heroes) == 0 {
+		return nil, nil
+	}
 	return heroes, nil
 }
 
+// CountHeroes returns number of stored heroes, it scans whole keyspace
+func (r *Redis) CountHeroes(ctx context.Context) (int, error) {
+	opts := radix.ScanOpts{
+		Command: "SCAN",
+		Pattern: heroPrefix + ".*",
+		Count:   100,
+	}
+	scanner := radix.NewScanner(ctxClient{r: r, ctx: ctx, op: "CountHeroes"}, opts)
+
+	var n int
+	var key string
+	for scanner.Next(&key) {
+		n++
+	}
+
+	if err := scanner.Close(); err != nil {
+		return 0, wrapErr("CountHeroes", "", err)
+	}
+	return n, nil
+}
+
 // GetHero gets hero by ID
-func (r *Redis) GetHero(id string) (storage.Hero, error) {
-	var exists int
-	if err := r.client.Do(radix.Cmd(&exists, "EXISTS", heroPrefix+"."+id)); err != nil {
-		return storage.Hero{}, err
+// hero and its owner are read in single MGET, so there is no gap between
+// existence check and read where concurrent delete could happen
+func (r *Redis) GetHero(ctx context.Context, id string) (storage.Hero, error) {
+	heroes, err := r.getHeroes(ctx, "GetHero", []string{id})
+	if err != nil {
+		return storage.Hero{}, wrapErr("GetHero", id, err)
 	}
 
-	if exists == 0 {
-		return storage.Hero{}, storage.NewErrHeroNotExist("hero not exist")
+	if len(heroes) == 0 {
+		return storage.Hero{}, storage.NewError(storage.ErrNotFound, "GetHero", id, nil)
+	}
+
+	return heroes[0], nil
+}
+
+// GetHeroesByID gets heroes with given IDs in single MGET
+// heroes which don't exist are omitted from result
+func (r *Redis) GetHeroesByID(ctx context.Context, ids []string) ([]storage.Hero, error) {
+	heroes, err := r.getHeroes(ctx, "GetHeroesByID", ids)
+	if err != nil {
+		return []storage.Hero{}, wrapErr("GetHeroesByID", "", err)
+	}
+	return heroes, nil
+}
+
+// GetHeroesByOwner gets heroes created by owner
+// set of owned heroes isn't cleaned up on delete, so heroes are filtered
+// by their current owner
+func (r *Redis) GetHeroesByOwner(ctx context.Context, owner string) ([]storage.Hero, error) {
+	var ids []string
+	if err := r.do(ctx, "GetHeroesByOwner", radix.Cmd(&ids, "SMEMBERS", ownedPrefix+"."+owner)); err != nil {
+		return []storage.Hero{}, wrapErr("GetHeroesByOwner", "", err)
+	}
+	// IDs are numeric, shorter one is smaller
+	sort.Slice(ids, func(i, j int) bool {
+		if len(ids[i]) != len(ids[j]) {
+			return len(ids[i]) < len(ids[j])
+		}
+		return ids[i] < ids[j]
+	})
+
+	heroes, err := r.getHeroes(ctx, "GetHeroesByOwner", ids)
+	if err != nil {
+		return []storage.Hero{}, wrapErr("GetHeroesByOwner", "", err)
+	}
+
+	owned := make([]storage.Hero, 0, len(heroes))
+	for _, h := range heroes {
+		if h.Owner == owner {
+			owned = append(owned, h)
+		}
+	}
+	return owned, nil
+}
+
+// getHeroes reads names and owners of heroes in single MGET
+// heroes which don't exist are omitted from result
+func (r *Redis) getHeroes(ctx context.Context, op string, ids []string) ([]storage.Hero, error) {
+	if len(ids) == 0 {
+		return []storage.Hero{}, nil
+	}
+
+	keys := make([]string, 0, 2*len(ids))
+	for _, id := range ids {
+		keys = append(keys, heroPrefix+"."+id, ownerPrefix+"."+id)
+	}
+
+	values := make([]radix.MaybeNil, len(keys))
+	for i := range values {
+		values[i].Rcv = new(string)
+	}
+	if err := r.do(ctx, op, radix.Cmd(&values, "MGET", keys...)); err != nil {
+		return []storage.Hero{}, err
+	}
+
+	heroes := make([]storage.Hero, 0, len(ids))
+	for i, id := range ids {
+		name, owner := values[2*i], values[2*i+1]
+		if name.Nil {
+			continue
+		}
+		h := storage.Hero{ID: id, Name: *name.Rcv.(*string)}
+		if !owner.Nil {
+			h.Owner = *owner.Rcv.(*string)
+		}
+		heroes = append(heroes, h)
+	}
+
+	return heroes, nil
+}
+
+// write scripts reply one of these codes
+const (
+	replyNotFound  = 0
+	replyOK        = 1
+	replyForbidden = -1
+)
+
+// createScript sets name of hero, new hero is owned by caller while existing
+// one keeps its owner and may be overwritten by owner or admin only
+// KEYS: hero, owner of hero, heroes of caller; ARGV: name, caller, admin, id
+var createScript = radix.NewEvalScript(3, `
+local owner = redis.call('GET', KEYS[2])
+if redis.call('EXISTS', KEYS[1]) == 1 and ARGV[3] ~= '1' and owner ~= ARGV[2] then
+	return -1
+end
+redis.call('SET', KEYS[1], ARGV[1])
+if not owner then
+	redis.call('SET', KEYS[2], ARGV[2])
+	redis.call('SADD', KEYS[3], ARGV[4])
+end
+return 1
+`)
+
+// updateScript sets name of existing hero owned by caller
+// KEYS: hero, owner of hero; ARGV: name, caller, admin
+var updateScript = radix.NewEvalScript(2, `
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+if ARGV[3] ~= '1' and redis.call('GET', KEYS[2]) ~= ARGV[2] then
+	return -1
+end
+redis.call('SET', KEYS[1], ARGV[1])
+return 1
+`)
+
+// deleteScript deletes existing hero owned by caller and removes it from
+// heroes of its owner, key of owner's heroes is known only after owner is read
+// KEYS: hero, owner of hero; ARGV: caller, admin, prefix of owned heroes, id
+var deleteScript = radix.NewEvalScript(2, `
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+local owner = redis.call('GET', KEYS[2])
+if ARGV[2] ~= '1' and owner ~= ARGV[1] then
+	return -1
+end
+redis.call('DEL', KEYS[1], KEYS[2])
+if owner then
+	redis.call('SREM', ARGV[3] .. '.' .. owner, ARGV[4])
+end
+return 1
+`)
+
+// CreateHero creates new hero by ID and Name owned by owner
+// ownership check and write are done atomically by script
+func (r *Redis) CreateHero(ctx context.Context, id, name string, owner storage.Owner) error {
+	var reply int
+	action := createScript.Cmd(&reply, heroPrefix+"."+id, ownerPrefix+"."+id, ownedPrefix+"."+owner.ID,
+		name, owner.ID, flag(owner.Admin), id)
+	if err := r.do(ctx, "CreateHero", action); err != nil {
+		return wrapErr("CreateHero", id, err)
 	}
 
-	var name string
-	if err := r.client.Do(radix.Cmd(&name, "GET", heroPrefix+"."+id)); err != nil {
-		return storage.Hero{}, err
+	if reply == replyForbidden {
+		return storage.NewError(storage.ErrForbidden, "CreateHero", id, nil)
 	}
 
-	return storage.Hero{ID: id, Name: name}, nil
+	r.notify(notifier.OpCreate, id)
+	return nil
 }
 
-// CreateHero creates new hero by ID and Name
-func (r *Redis) CreateHero(id, name string) error {
-	return r.client.Do(radix.Cmd(&name, "SET", heroPrefix+"."+id, name))
+// UpdateHero updates name of existing hero
+// existence check, ownership check and write are done atomically by script
+func (r *Redis) UpdateHero(ctx context.Context, id, name string, owner storage.Owner) error {
+	var reply int
+	action := updateScript.Cmd(&reply, heroPrefix+"."+id, ownerPrefix+"."+id, name, owner.ID, flag(owner.Admin))
+	if err := r.do(ctx, "UpdateHero", action); err != nil {
+		return wrapErr("UpdateHero", id, err)
+	}
+
+	switch reply {
+	case replyNotFound:
+		return storage.NewError(storage.ErrNotFound, "UpdateHero", id, nil)
+	case replyForbidden:
+		return storage.NewError(storage.ErrForbidden, "UpdateHero", id, nil)
+	}
+
+	r.notify(notifier.OpUpdate, id)
+	return nil
 }
 
 // DeleteHero deletes hero by ID
-func (r *Redis) DeleteHero(id string) error {
-	return r.client.Do(radix.Cmd(nil, "DEL", heroPrefix+"."+id))
+// returns storage.ErrNotFound when hero doesn't exist
+func (r *Redis) DeleteHero(ctx context.Context, id string, owner storage.Owner) error {
+	var reply int
+	action := deleteScript.Cmd(&reply, heroPrefix+"."+id, ownerPrefix+"."+id,
+		owner.ID, flag(owner.Admin), ownedPrefix, id)
+	if err := r.do(ctx, "DeleteHero", action); err != nil {
+		return wrapErr("DeleteHero", id, err)
+	}
+
+	switch reply {
+	case replyNotFound:
+		return storage.NewError(storage.ErrNotFound, "DeleteHero", id, nil)
+	case replyForbidden:
+		return storage.NewError(storage.ErrForbidden, "DeleteHero", id, nil)
+	}
+
+	r.notify(notifier.OpDelete, id)
+	return nil
+}
+
+func flag(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// do runs action on single connection, network connection is interrupted as
+// soon as ctx is done, so driver doesn't wait longer than caller does
+// time spent in storage after cancellation is logged
+func (r *Redis) do(ctx context.Context, op string, a radix.Action) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "redis",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.system", "redis"), attribute.String("storage.method", op)))
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+	// command is found by encoding it, so it's done for sampled spans only
+	if span.IsRecording() {
+		if cmd := commandOf(a); cmd != "" {
+			span.SetName("redis " + cmd)
+			span.SetAttributes(attribute.String("db.operation", cmd))
+		}
+	}
+
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if ctx.Done() == nil {
+		return r.client.Do(a)
+	}
+
+	return r.client.Do(radix.WithConn("", func(c radix.Conn) error {
+		nc := c.NetConn()
+		defer nc.SetDeadline(time.Time{})
+
+		// connection deadline is set only once ctx is done (including its
+		// own deadline), so interrupted call always reports ctx error
+		var canceledAt time.Time
+		canceled := make(chan struct{})
+		stop := context.AfterFunc(ctx, func() {
+			canceledAt = time.Now()
+			// deadline in the past unblocks pending read or write
+			nc.SetDeadline(canceledAt)
+			close(canceled)
+		})
+
+		err := c.Do(a)
+		if stop() {
+			return err
+		}
+
+		<-canceled
+		r.logger.Warn().
+			Err(ctx.Err()).
+			Str("op", op).
+			Dur("after_cancel", time.Since(canceledAt)).
+			Msg("Storage call outlived request context")
+		if err != nil {
+			// the reason why connection was interrupted is context
+			err = errors.Join(ctx.Err(), err)
+		}
+		return err
+	}))
+}
+
+// commandOf returns name of redis command run by action, e.g. MGET or
+// EVALSHA, or empty string when action isn't single command
+func commandOf(a radix.Action) string {
+	m, ok := a.(resp.Marshaler)
+	if !ok {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := m.MarshalRESP(&buf); err != nil {
+		return ""
+	}
+	var args []string
+	if err := resp2.RawMessage(buf.Bytes()).UnmarshalInto(resp2.Any{I: &args}); err != nil || len(args) == 0 {
+		return ""
+	}
+	return strings.ToUpper(args[0])
+}
+
+// ctxClient is radix.Client which runs every action with context,
+// it lets helpers like radix.Scanner respect request deadline
+type ctxClient struct {
+	r   *Redis
+	ctx context.Context
+	op  string
+}
+
+func (c ctxClient) Do(a radix.Action) error {
+	return c.r.do(c.ctx, c.op, a)
+}
+
+func (c ctxClient) Close() error {
+	return nil
+}
+
+// Stats returns usage of connection pool
+func (r *Redis) Stats() map[string]uint64 {
+	p, ok := r.client.(*radix.Pool)
+	if !ok {
+		return map[string]uint64{}
+	}
+
+	// pool may keep more idle connections than its size in overflow buffer
+	idle := p.NumAvailConns()
+	inUse := 0
+	if idle < poolSize {
+		inUse = poolSize - idle
+	}
+	return map[string]uint64{
+		"pool_size":   poolSize,
+		"pool_idle":   uint64(idle),
+		"pool_in_use": uint64(inUse),
+	}
+}
+
+// Close closes connection pool
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
+
+func (r *Redis) notify(op, id string) {
+	if r.notifier == nil {
+		return
+	}
+	r.notifier.Publish(notifier.Event{Op: op, ID: id})
+}
+
+// connFunc returns radix.ConnFunc which authenticates connection
+// when password is set
+func connFunc(password string) radix.ConnFunc {
+	return func(network, addr string) (radix.Conn, error) {
+		if password == "" {
+			return radix.Dial(network, addr)
+		}
+		return radix.Dial(network, addr, radix.DialAuthPass(password))
+	}
 }
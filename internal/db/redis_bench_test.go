@@ -0,0 +1,102 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/bliuchak/heroes/internal/storage"
+	"github.com/mediocregopher/radix/v3"
+)
+
+// getHeroExistsGet is the previous read path: EXISTS followed by GET
+// it's kept here only to compare with Redis.GetHero
+func getHeroExistsGet(client radix.Client, id string) (storage.Hero, error) {
+	var exists int
+	if err := client.Do(radix.Cmd(&exists, "EXISTS", heroPrefix+"."+id)); err != nil {
+		return storage.Hero{}, err
+	}
+
+	if exists == 0 {
+		return storage.Hero{}, storage.NewError(storage.ErrNotFound, "GetHero", id, nil)
+	}
+
+	var name string
+	if err := client.Do(radix.Cmd(&name, "GET", heroPrefix+"."+id)); err != nil {
+		return storage.Hero{}, err
+	}
+
+	return storage.Hero{ID: id, Name: name}, nil
+}
+
+func benchStub() radix.Client {
+	return radix.Stub("", "", func(args []string) interface{} {
+		switch args[0] {
+		case "EXISTS":
+			return 1
+		case "GET":
+			return "Batman"
+		case "MGET":
+			return []interface{}{"Batman", nil}
+		default:
+			return fmt.Errorf("testStub doesn't support command %q", args[0])
+		}
+	})
+}
+
+// benchRedis returns pool connected to local redis with one hero stored
+// address can be changed with REDIS_ADDR env variable
+func benchRedis(b *testing.B) radix.Client {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		addr = "localhost:6379"
+	}
+
+	pool, err := radix.NewPool("tcp", addr, 10)
+	if err != nil {
+		b.Skipf("redis is not available on %s: %v", addr, err)
+	}
+
+	if err := pool.Do(radix.Cmd(nil, "SET", heroPrefix+".bench", "Batman")); err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		pool.Do(radix.Cmd(nil, "DEL", heroPrefix+".bench"))
+		pool.Close()
+	})
+
+	return pool
+}
+
+func BenchmarkRedis_GetHero(b *testing.B) {
+	clients := []struct {
+		name   string
+		client func(b *testing.B) radix.Client
+	}{
+		{name: "stub", client: func(*testing.B) radix.Client { return benchStub() }},
+		{name: "redis", client: benchRedis},
+	}
+
+	for _, c := range clients {
+		b.Run(c.name+"/exists_get", func(b *testing.B) {
+			client := c.client(b)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := getHeroExistsGet(client, "bench"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(c.name+"/mget", func(b *testing.B) {
+			r := Redis{client: c.client(b)}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := r.GetHero(context.Background(), "bench"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
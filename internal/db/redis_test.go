@@ -1,13 +1,27 @@
 package db
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
 	"testing"
+	"time"
 
+	"github.com/bliuchak/heroes/internal/notifier"
 	"github.com/bliuchak/heroes/internal/storage"
 	"github.com/mediocregopher/radix/v3"
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
 type statusExpected struct {
@@ -53,7 +67,7 @@ func TestDbRedis_Status(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := Redis{client: tt.redisStub}
-			res, err := r.Status()
+			res, err := r.Status(context.Background())
 
 			if tt.expected.isError {
 				assert.Error(t, err)
@@ -88,8 +102,8 @@ func TestDbRedis_GetHeroes(t *testing.T) {
 						return []interface{}{"1", keys}
 					}
 					return []interface{}{"0", []string{}}
-				case "GET":
-					return "Batman"
+				case "MGET":
+					return []interface{}{"Batman", "apikey:1"}
 				default:
 					return fmt.Errorf("testStub doesn't support command %q", args[0])
 				}
@@ -97,14 +111,15 @@ func TestDbRedis_GetHeroes(t *testing.T) {
 			expected: getHeroesExpected{
 				heroes: []storage.Hero{
 					{
-						ID:   "1",
-						Name: "Batman",
+						ID:    "1",
+						Name:  "Batman",
+						Owner: "apikey:1",
 					},
 				},
 			},
 		},
 		{
-			name: "should return error on GET for scanned key",
+			name: "should return error on MGET for scanned keys",
 			redisStub: radix.Stub("", "", func(args []string) interface{} {
 				switch args[0] {
 				case "SCAN":
@@ -113,8 +128,8 @@ func TestDbRedis_GetHeroes(t *testing.T) {
 						return []interface{}{"1", keys}
 					}
 					return []interface{}{"0", []string{}}
-				case "GET":
-					return errors.New("GET error")
+				case "MGET":
+					return errors.New("MGET error")
 				default:
 					return fmt.Errorf("testStub doesn't support command %q", args[0])
 				}
@@ -122,7 +137,7 @@ func TestDbRedis_GetHeroes(t *testing.T) {
 			expected: getHeroesExpected{
 				isError: true,
 				heroes:  []storage.Hero{},
-				error:   errors.New("GET error"),
+				error:   storage.NewError(storage.ErrInternal, "GetHeroes", "", errors.New("MGET error")),
 			},
 		},
 		{
@@ -138,14 +153,14 @@ func TestDbRedis_GetHeroes(t *testing.T) {
 			expected: getHeroesExpected{
 				isError: true,
 				heroes:  []storage.Hero{},
-				error:   errors.New("scanner error"),
+				error:   storage.NewError(storage.ErrInternal, "GetHeroes", "", errors.New("scanner error")),
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := Redis{client: tt.redisStub}
-			res, err := r.GetHeroes()
+			res, err := r.GetHeroes(context.Background())
 
 			if tt.expected.isError {
 				assert.Equal(t, err, tt.expected.error)
@@ -158,6 +173,28 @@ func TestDbRedis_GetHeroes(t *testing.T) {
 	}
 }
 
+func TestDbRedis_CountHeroes(t *testing.T) {
+	r := Redis{client: radix.Stub("", "", func(args []string) interface{} {
+		if args[0] != "SCAN" {
+			return fmt.Errorf("testStub doesn't support command %q", args[0])
+		}
+		if args[1] == "0" {
+			return []interface{}{"1", []string{"hero.1", "hero.2"}}
+		}
+		return []interface{}{"0", []string{"hero.3"}}
+	})}
+
+	n, err := r.CountHeroes(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+
+	r = Redis{client: radix.Stub("", "", func(args []string) interface{} {
+		return errors.New("scanner error")
+	})}
+	_, err = r.CountHeroes(context.Background())
+	assert.Equal(t, storage.NewError(storage.ErrInternal, "CountHeroes", "", errors.New("scanner error")), err)
+}
+
 type getHeroExpected struct {
 	isError bool
 	error   error
@@ -171,60 +208,62 @@ func TestDbRedis_GetHero(t *testing.T) {
 		expected  getHeroExpected
 	}{
 		{
-			name: "should return error on EXISTS command",
+			name: "should return error on MGET command",
 			redisStub: radix.Stub("", "", func(args []string) interface{} {
 				switch args[0] {
-				case "EXISTS":
-					return errors.New("EXISTS error")
+				case "MGET":
+					return errors.New("MGET error")
 				default:
 					return fmt.Errorf("testStub doesn't support command %q", args[0])
 				}
 			}),
 			expected: getHeroExpected{
 				isError: true,
-				error:   errors.New("EXISTS error"),
+				error:   storage.NewError(storage.ErrInternal, "GetHero", "1", errors.New("MGET error")),
 			},
 		},
 		{
 			name: "should return error hero not existing",
 			redisStub: radix.Stub("", "", func(args []string) interface{} {
 				switch args[0] {
-				case "EXISTS":
-					return 0
+				case "MGET":
+					return []interface{}{nil, nil}
 				default:
 					return fmt.Errorf("testStub doesn't support command %q", args[0])
 				}
 			}),
 			expected: getHeroExpected{
 				isError: true,
-				error:   storage.NewErrHeroNotExist("hero not exist"),
+				error:   storage.NewError(storage.ErrNotFound, "GetHero", "1", nil),
 			},
 		},
 		{
-			name: "should return error on GET command",
+			name: "should return correct hero information",
 			redisStub: radix.Stub("", "", func(args []string) interface{} {
 				switch args[0] {
-				case "EXISTS":
-					return 1
-				case "GET":
-					return errors.New("GET error")
+				case "MGET":
+					if len(args) != 3 || args[1] != "hero.1" || args[2] != "heroowner.1" {
+						return fmt.Errorf("unexpected keys %q", args[1:])
+					}
+					return []interface{}{"Batman", "apikey:1"}
 				default:
 					return fmt.Errorf("testStub doesn't support command %q", args[0])
 				}
 			}),
 			expected: getHeroExpected{
-				isError: true,
-				error:   errors.New("GET error"),
+				hero: storage.Hero{
+					ID:    "1",
+					Name:  "Batman",
+					Owner: "apikey:1",
+				},
 			},
 		},
 		{
-			name: "should return correct hero information",
+			name: "should return hero created before owners were recorded",
 			redisStub: radix.Stub("", "", func(args []string) interface{} {
 				switch args[0] {
-				case "EXISTS":
-					return 1
-				case "GET":
-					return "Batman"
+				case "MGET":
+					return []interface{}{"Batman", nil}
 				default:
 					return fmt.Errorf("testStub doesn't support command %q", args[0])
 				}
@@ -240,7 +279,7 @@ func TestDbRedis_GetHero(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := Redis{client: tt.redisStub}
-			res, err := r.GetHero("1")
+			res, err := r.GetHero(context.Background(), "1")
 
 			if tt.expected.isError {
 				assert.Equal(t, err, tt.expected.error)
@@ -253,111 +292,332 @@ func TestDbRedis_GetHero(t *testing.T) {
 	}
 }
 
-type createHeroExpected struct {
-	isError bool
-	error   error
-}
-
-func TestDbRedis_CreateHero(t *testing.T) {
+func TestDbRedis_GetHeroesByID(t *testing.T) {
 	tests := []struct {
 		name      string
+		ids       []string
 		redisStub radix.Client
-		expected  createHeroExpected
+		expected  getHeroesExpected
 	}{
 		{
-			name: "should return error on SET command",
+			name: "should return error on MGET command",
+			ids:  []string{"1"},
 			redisStub: radix.Stub("", "", func(args []string) interface{} {
 				switch args[0] {
-				case "SET":
-					return errors.New("SET error")
+				case "MGET":
+					return errors.New("MGET error")
 				default:
 					return fmt.Errorf("testStub doesn't support command %q", args[0])
 				}
 			}),
-			expected: createHeroExpected{
+			expected: getHeroesExpected{
 				isError: true,
-				error:   errors.New("SET error"),
+				heroes:  []storage.Hero{},
+				error:   storage.NewError(storage.ErrInternal, "GetHeroesByID", "", errors.New("MGET error")),
 			},
 		},
 		{
-			name: "should return no errors",
+			name: "should return only existing heroes",
+			ids:  []string{"1", "2", "3"},
 			redisStub: radix.Stub("", "", func(args []string) interface{} {
 				switch args[0] {
-				case "SET":
-					return nil
+				case "MGET":
+					return []interface{}{"Batman", "apikey:1", nil, nil, "Flash", nil}
 				default:
 					return fmt.Errorf("testStub doesn't support command %q", args[0])
 				}
 			}),
-			expected: createHeroExpected{
-				isError: false,
+			expected: getHeroesExpected{
+				heroes: []storage.Hero{
+					{ID: "1", Name: "Batman", Owner: "apikey:1"},
+					{ID: "3", Name: "Flash"},
+				},
+			},
+		},
+		{
+			name: "should not call redis without ids",
+			redisStub: radix.Stub("", "", func(args []string) interface{} {
+				return fmt.Errorf("testStub doesn't support command %q", args[0])
+			}),
+			expected: getHeroesExpected{
+				heroes: []storage.Hero{},
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			r := Redis{client: tt.redisStub}
-			err := r.CreateHero("1", "Batman")
+			res, err := r.GetHeroesByID(context.Background(), tt.ids)
 
 			if tt.expected.isError {
 				assert.Equal(t, err, tt.expected.error)
 			} else {
 				assert.NoError(t, err)
 			}
+
+			assert.Equal(t, tt.expected.heroes, res)
 		})
 	}
 }
 
-type deleteHeroExpected struct {
-	isError bool
-	error   error
-}
-
-func TestDbRedis_DeleteHero(t *testing.T) {
+func TestDbRedis_GetHeroesByOwner(t *testing.T) {
 	tests := []struct {
 		name      string
 		redisStub radix.Client
-		expected  deleteHeroExpected
+		expected  getHeroesExpected
 	}{
 		{
-			name: "should return error on DEL command",
+			name: "should return error on SMEMBERS command",
 			redisStub: radix.Stub("", "", func(args []string) interface{} {
 				switch args[0] {
-				case "DEL":
-					return errors.New("DEL error")
+				case "SMEMBERS":
+					return errors.New("SMEMBERS error")
 				default:
 					return fmt.Errorf("testStub doesn't support command %q", args[0])
 				}
 			}),
-			expected: deleteHeroExpected{
+			expected: getHeroesExpected{
 				isError: true,
-				error:   errors.New("DEL error"),
+				heroes:  []storage.Hero{},
+				error:   storage.NewError(storage.ErrInternal, "GetHeroesByOwner", "", errors.New("SMEMBERS error")),
 			},
 		},
 		{
-			name: "should return no errors",
+			name: "should return sorted heroes still owned by owner",
 			redisStub: radix.Stub("", "", func(args []string) interface{} {
 				switch args[0] {
-				case "DEL":
-					return nil
+				case "SMEMBERS":
+					if args[1] != "ownedheroes.apikey:1" {
+						return fmt.Errorf("unexpected key %q", args[1])
+					}
+					return []string{"10", "2", "3"}
+				case "MGET":
+					if args[1] != "hero.2" || args[3] != "hero.3" || args[5] != "hero.10" {
+						return fmt.Errorf("unexpected keys %q", args[1:])
+					}
+					return []interface{}{"Batman", "apikey:1", nil, nil, "Flash", "apikey:2"}
 				default:
 					return fmt.Errorf("testStub doesn't support command %q", args[0])
 				}
 			}),
+			expected: getHeroesExpected{
+				heroes: []storage.Hero{
+					{ID: "2", Name: "Batman", Owner: "apikey:1"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := Redis{client: tt.redisStub}
+			res, err := r.GetHeroesByOwner(context.Background(), "apikey:1")
+
+			if tt.expected.isError {
+				assert.Equal(t, err, tt.expected.error)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.expected.heroes, res)
+		})
+	}
+}
+
+// scriptStub returns client which replies to EVALSHA with reply and records
+// keys and arguments script was called with
+func scriptStub(reply interface{}, called *[]string) radix.Client {
+	return radix.Stub("", "", func(args []string) interface{} {
+		switch args[0] {
+		case "EVALSHA":
+			if called != nil {
+				*called = args[3:]
+			}
+			return reply
+		default:
+			return fmt.Errorf("testStub doesn't support command %q", args[0])
+		}
+	})
+}
+
+type createHeroExpected struct {
+	isError bool
+	error   error
+	args    []string
+}
+
+func TestDbRedis_CreateHero(t *testing.T) {
+	tests := []struct {
+		name     string
+		owner    storage.Owner
+		reply    interface{}
+		expected createHeroExpected
+	}{
+		{
+			name:  "should return error on EVALSHA command",
+			owner: storage.Owner{ID: "apikey:1"},
+			reply: errors.New("EVALSHA error"),
+			expected: createHeroExpected{
+				isError: true,
+				error:   storage.NewError(storage.ErrInternal, "CreateHero", "1", errors.New("EVALSHA error")),
+			},
+		},
+		{
+			name:  "should return error hero owned by other client",
+			owner: storage.Owner{ID: "apikey:2"},
+			reply: replyForbidden,
+			expected: createHeroExpected{
+				isError: true,
+				error:   storage.NewError(storage.ErrForbidden, "CreateHero", "1", nil),
+			},
+		},
+		{
+			name:  "should return no errors",
+			owner: storage.Owner{ID: "apikey:1"},
+			reply: replyOK,
+			expected: createHeroExpected{
+				args: []string{"hero.1", "heroowner.1", "ownedheroes.apikey:1", "Batman", "apikey:1", "0", "1"},
+			},
+		},
+		{
+			name:  "should pass admin flag to script",
+			owner: storage.Owner{ID: "admin", Admin: true},
+			reply: replyOK,
+			expected: createHeroExpected{
+				args: []string{"hero.1", "heroowner.1", "ownedheroes.admin", "Batman", "admin", "1", "1"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args []string
+			r := Redis{client: scriptStub(tt.reply, &args)}
+			err := r.CreateHero(context.Background(), "1", "Batman", tt.owner)
+
+			if tt.expected.isError {
+				assert.Equal(t, err, tt.expected.error)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expected.args, args)
+			}
+		})
+	}
+}
+
+type updateHeroExpected struct {
+	isError bool
+	error   error
+}
+
+func TestDbRedis_UpdateHero(t *testing.T) {
+	tests := []struct {
+		name     string
+		reply    interface{}
+		expected updateHeroExpected
+	}{
+		{
+			name:  "should return error on EVALSHA command",
+			reply: errors.New("EVALSHA error"),
+			expected: updateHeroExpected{
+				isError: true,
+				error:   storage.NewError(storage.ErrInternal, "UpdateHero", "1", errors.New("EVALSHA error")),
+			},
+		},
+		{
+			name:  "should return error hero not existing",
+			reply: replyNotFound,
+			expected: updateHeroExpected{
+				isError: true,
+				error:   storage.NewError(storage.ErrNotFound, "UpdateHero", "1", nil),
+			},
+		},
+		{
+			name:  "should return error hero owned by other client",
+			reply: replyForbidden,
+			expected: updateHeroExpected{
+				isError: true,
+				error:   storage.NewError(storage.ErrForbidden, "UpdateHero", "1", nil),
+			},
+		},
+		{
+			name:  "should return no errors",
+			reply: replyOK,
+			expected: updateHeroExpected{
+				isError: false,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var args []string
+			r := Redis{client: scriptStub(tt.reply, &args)}
+			err := r.UpdateHero(context.Background(), "1", "Batman", storage.Owner{ID: "apikey:1"})
+
+			if tt.expected.isError {
+				assert.Equal(t, err, tt.expected.error)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, []string{"hero.1", "heroowner.1", "Batman", "apikey:1", "0"}, args)
+			}
+		})
+	}
+}
+
+type deleteHeroExpected struct {
+	isError bool
+	error   error
+	args    []string
+}
+
+func TestDbRedis_DeleteHero(t *testing.T) {
+	tests := []struct {
+		name     string
+		reply    interface{}
+		expected deleteHeroExpected
+	}{
+		{
+			name:  "should return error on EVALSHA command",
+			reply: errors.New("EVALSHA error"),
+			expected: deleteHeroExpected{
+				isError: true,
+				error:   storage.NewError(storage.ErrInternal, "DeleteHero", "1", errors.New("EVALSHA error")),
+			},
+		},
+		{
+			name:  "should return error hero not existing",
+			reply: replyNotFound,
+			expected: deleteHeroExpected{
+				isError: true,
+				error:   storage.NewError(storage.ErrNotFound, "DeleteHero", "1", nil),
+			},
+		},
+		{
+			name:  "should return error hero owned by other client",
+			reply: replyForbidden,
+			expected: deleteHeroExpected{
+				isError: true,
+				error:   storage.NewError(storage.ErrForbidden, "DeleteHero", "1", nil),
+			},
+		},
+		{
+			name:  "should return no errors",
+			reply: replyOK,
 			expected: deleteHeroExpected{
 				isError: false,
+				args:    []string{"hero.1", "heroowner.1", "apikey:1", "0", "ownedheroes", "1"},
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			r := Redis{client: tt.redisStub}
-			err := r.DeleteHero("1")
+			var args []string
+			r := Redis{client: scriptStub(tt.reply, &args)}
+			err := r.DeleteHero(context.Background(), "1", storage.Owner{ID: "apikey:1"})
 
 			if tt.expected.isError {
 				assert.Equal(t, err, tt.expected.error)
 			} else {
 				assert.NoError(t, err)
+				assert.Equal(t, tt.expected.args, args)
 			}
 		})
 	}
@@ -367,3 +627,140 @@ func TestDb_NewPool(t *testing.T) {
 	_, err := NewRedis("", "", "0")
 	assert.Error(t, err)
 }
+
+func TestDbRedis_Notify(t *testing.T) {
+	n := notifier.NewLocal()
+	var events []notifier.Event
+	n.Subscribe(func(e notifier.Event) { events = append(events, e) })
+
+	r := Redis{client: scriptStub(replyOK, nil)}
+	r.SetNotifier(n)
+
+	owner := storage.Owner{ID: "apikey:1"}
+	assert.NoError(t, r.CreateHero(context.Background(), "1", "Batman", owner))
+	assert.NoError(t, r.DeleteHero(context.Background(), "1", owner))
+
+	assert.Equal(t, []notifier.Event{
+		{Op: notifier.OpCreate, ID: "1"},
+		{Op: notifier.OpDelete, ID: "1"},
+	}, events)
+}
+
+func TestDbRedis_NotifyNothingDeleted(t *testing.T) {
+	n := notifier.NewLocal()
+	var events []notifier.Event
+	n.Subscribe(func(e notifier.Event) { events = append(events, e) })
+
+	for _, reply := range []int{replyNotFound, replyForbidden} {
+		r := Redis{client: scriptStub(reply, nil)}
+		r.SetNotifier(n)
+
+		assert.Error(t, r.DeleteHero(context.Background(), "1", storage.Owner{ID: "apikey:1"}))
+	}
+	assert.Empty(t, events)
+}
+
+// silentConn returns radix connection to server which reads commands
+// but never replies
+func silentConn(t *testing.T) radix.Conn {
+	client, server := net.Pipe()
+	t.Cleanup(func() { server.Close() })
+	go io.Copy(ioutil.Discard, server)
+
+	return radix.NewConn(client)
+}
+
+func TestDbRedis_Deadline(t *testing.T) {
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		ctx     func() (context.Context, context.CancelFunc)
+		err     error
+	}{
+		{
+			name: "should stop on context deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 20*time.Millisecond)
+			},
+			err: context.DeadlineExceeded,
+		},
+		{
+			name:    "should stop on default timeout",
+			timeout: 20 * time.Millisecond,
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+			err: context.DeadlineExceeded,
+		},
+		{
+			name: "should stop when context is canceled",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(20*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			err: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var log bytes.Buffer
+			r := Redis{client: silentConn(t), logger: zerolog.New(&log)}
+			r.SetTimeout(tt.timeout)
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			start := time.Now()
+			_, err := r.GetHero(ctx, "1")
+
+			assert.Less(t, time.Since(start), time.Second)
+			assert.True(t, errors.Is(err, tt.err))
+			assert.True(t, errors.Is(err, storage.ErrUnavailable))
+			assert.Contains(t, log.String(), `"after_cancel"`)
+		})
+	}
+}
+
+func TestDbRedis_CanceledBeforeCall(t *testing.T) {
+	called := false
+	stub := radix.Stub("", "", func(args []string) interface{} {
+		called = true
+		return "Batman"
+	})
+	r := Redis{client: stub}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := r.GetHero(ctx, "1")
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.False(t, called)
+}
+
+func TestDbRedis_TracesCommands(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	r := Redis{client: scriptStub(int64(replyForbidden), nil)}
+	err := r.DeleteHero(context.Background(), "1", storage.Owner{ID: "apikey:1"})
+	assert.Error(t, err)
+
+	r = Redis{client: radix.Stub("", "", func(args []string) interface{} {
+		return errors.New("connection refused")
+	})}
+	_, err = r.GetHero(context.Background(), "1")
+	assert.Error(t, err)
+
+	spans := sr.Ended()
+	if assert.Len(t, spans, 2) {
+		assert.Equal(t, "redis EVALSHA", spans[0].Name())
+		assert.Contains(t, spans[0].Attributes(), attribute.String("storage.method", "DeleteHero"))
+		assert.Equal(t, codes.Unset, spans[0].Status().Code)
+
+		assert.Equal(t, "redis MGET", spans[1].Name())
+		assert.Contains(t, spans[1].Attributes(), attribute.String("db.operation", "MGET"))
+		assert.Equal(t, codes.Error, spans[1].Status().Code)
+	}
+}
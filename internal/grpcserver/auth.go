@@ -0,0 +1,112 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/bliuchak/heroes/internal/auth"
+	"github.com/bliuchak/heroes/internal/grpcserver/heroespb"
+	"github.com/bliuchak/heroes/internal/server/middleware"
+	"github.com/bliuchak/heroes/internal/server/problem"
+	"github.com/bliuchak/heroes/internal/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// scopes required by methods, methods which aren't listed don't require
+// credentials, like /status of http server
+var scopes = map[string]string{
+	heroespb.HeroService_GetHero_FullMethodName:    auth.ScopeRead,
+	heroespb.HeroService_ListHeroes_FullMethodName: auth.ScopeRead,
+	heroespb.HeroService_CreateHero_FullMethodName: auth.ScopeWrite,
+	heroespb.HeroService_DeleteHero_FullMethodName: auth.ScopeWrite,
+}
+
+// credentials are metadata keys which carry the same credentials as
+// http headers
+var credentials = map[string]string{
+	"x-api-key":     middleware.APIKeyHeader,
+	"authorization": "Authorization",
+}
+
+func (s *Server) unaryAuth(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := s.authenticate(ctx, info.FullMethod)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) streamAuth(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := s.authenticate(ss.Context(), info.FullMethod)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authStream{ServerStream: ss, ctx: ctx})
+}
+
+// authenticate passes principal of call to handlers via ctx, it uses the
+// same authenticators as http server, so API keys and JWT are accepted in
+// metadata. Every call is let in as anonymous admin when auth is disabled.
+func (s *Server) authenticate(ctx context.Context, method string) (context.Context, error) {
+	if !s.Config.Auth.Enabled {
+		return auth.NewContext(ctx, auth.Anonymous), nil
+	}
+	scope, ok := scopes[method]
+	if !ok {
+		return ctx, nil
+	}
+
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, method, nil)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for key, header := range credentials {
+		if v := md.Get(key); len(v) > 0 {
+			r.Header.Set(header, v[0])
+		}
+	}
+
+	for _, a := range middleware.Authenticators(s.keys(), s.Config.Auth.AdminKey, s.JWT) {
+		p, ok, err := a.Authenticate(r)
+		if err != nil {
+			var pr *problem.Problem
+			if errors.As(err, &pr) && pr.Status == http.StatusUnauthorized {
+				return nil, status.Error(codes.Unauthenticated, pr.Detail)
+			}
+			return nil, s.toStatus(err, "Unable to authenticate call")
+		}
+		if !ok {
+			continue
+		}
+		if !p.HasScope(scope) {
+			return nil, status.Error(codes.PermissionDenied, "scope "+scope+" is required")
+		}
+		return auth.NewContext(ctx, p), nil
+	}
+
+	return nil, status.Error(codes.Unauthenticated, "credentials are missing")
+}
+
+// keys returns storage of API keys, Storage is used when Keys is un-set
+func (s *Server) keys() storage.KeyStorager {
+	if s.Keys != nil {
+		return s.Keys
+	}
+	ks, _ := s.Storage.(storage.KeyStorager)
+	return ks
+}
+
+// authStream passes authenticated ctx to stream handlers
+type authStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,88 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/bliuchak/heroes/internal/auth"
+	"github.com/bliuchak/heroes/internal/config"
+	"github.com/bliuchak/heroes/internal/grpcserver/heroespb"
+	"github.com/bliuchak/heroes/internal/storage"
+	stmocks "github.com/bliuchak/heroes/internal/storage/mocks"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestServer_Authenticate(t *testing.T) {
+	key, token, err := auth.NewAPIKey("ci", []string{auth.ScopeRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := new(stmocks.KeyStorager)
+	keys.On("GetAPIKey", mock.Anything, key.ID).Return(key, nil)
+	keys.On("GetAPIKey", mock.Anything, "broken").Return(storage.APIKey{}, storage.NewError(storage.ErrUnavailable, "GetAPIKey", "broken", errors.New("EOF")))
+
+	s := new(stmocks.Storager)
+	s.On("GetHero", mock.Anything, "1").Return(storage.Hero{ID: "1", Name: "Batman"}, nil)
+	s.On("CreateHero", mock.Anything, "1", "Batman", storage.Owner{ID: "admin", Admin: true}).Return(nil)
+	s.On("Status", mock.Anything).Return("PONG", nil)
+
+	srv := NewServer(s, zerolog.Nop(), config.Config{Auth: config.Auth{Enabled: true, AdminKey: "root"}})
+	srv.Keys = keys
+	client := dial(t, srv)
+
+	create := func(ctx context.Context) error {
+		_, err := client.CreateHero(ctx, &heroespb.CreateHeroRequest{Hero: &heroespb.Hero{Id: "1", Name: "Batman"}})
+		return err
+	}
+	get := func(ctx context.Context) error {
+		_, err := client.GetHero(ctx, &heroespb.GetHeroRequest{Id: "1"})
+		return err
+	}
+	list := func(ctx context.Context) error {
+		stream, err := client.ListHeroes(ctx, &heroespb.ListHeroesRequest{})
+		if err != nil {
+			return err
+		}
+		_, err = stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	}
+	getStatus := func(ctx context.Context) error {
+		_, err := client.Status(ctx, &heroespb.StatusRequest{})
+		return err
+	}
+
+	tests := []struct {
+		name     string
+		call     func(ctx context.Context) error
+		md       []string
+		expected codes.Code
+	}{
+		{name: "should reject call without credentials", call: get, expected: codes.Unauthenticated},
+		{name: "should reject stream without credentials", call: list, expected: codes.Unauthenticated},
+		{name: "should reject invalid key", call: get, md: []string{"x-api-key", key.ID + ".wrong"}, expected: codes.Unauthenticated},
+		{name: "should reject invalid token", call: get, md: []string{"authorization", "Bearer abc"}, expected: codes.Unauthenticated},
+		{name: "should report storage failure", call: get, md: []string{"x-api-key", "broken.secret"}, expected: codes.Unavailable},
+		{name: "should reject write without scope", call: create, md: []string{"x-api-key", token}, expected: codes.PermissionDenied},
+		{name: "should accept read with key", call: get, md: []string{"x-api-key", token}, expected: codes.OK},
+		{name: "should create hero owned by principal", call: create, md: []string{"x-api-key", "root"}, expected: codes.OK},
+		{name: "should accept status without credentials", call: getStatus, expected: codes.OK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.AppendToOutgoingContext(context.Background(), tt.md...)
+
+			assert.Equal(t, tt.expected, status.Code(tt.call(ctx)))
+		})
+	}
+}
@@ -0,0 +1,593 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: heroes/v1/heroes.proto
+
+package heroespb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Hero struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id   string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+}
+
+func (x *Hero) Reset() {
+	*x = Hero{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_heroes_v1_heroes_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Hero) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Hero) ProtoMessage() {}
+
+func (x *Hero) ProtoReflect() protoreflect.Message {
+	mi := &file_heroes_v1_heroes_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Hero.ProtoReflect.Descriptor instead.
+func (*Hero) Descriptor() ([]byte, []int) {
+	return file_heroes_v1_heroes_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Hero) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Hero) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type GetHeroRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *GetHeroRequest) Reset() {
+	*x = GetHeroRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_heroes_v1_heroes_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetHeroRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetHeroRequest) ProtoMessage() {}
+
+func (x *GetHeroRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_heroes_v1_heroes_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetHeroRequest.ProtoReflect.Descriptor instead.
+func (*GetHeroRequest) Descriptor() ([]byte, []int) {
+	return file_heroes_v1_heroes_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetHeroRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type ListHeroesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListHeroesRequest) Reset() {
+	*x = ListHeroesRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_heroes_v1_heroes_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListHeroesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListHeroesRequest) ProtoMessage() {}
+
+func (x *ListHeroesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_heroes_v1_heroes_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListHeroesRequest.ProtoReflect.Descriptor instead.
+func (*ListHeroesRequest) Descriptor() ([]byte, []int) {
+	return file_heroes_v1_heroes_proto_rawDescGZIP(), []int{2}
+}
+
+type CreateHeroRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Hero *Hero `protobuf:"bytes,1,opt,name=hero,proto3" json:"hero,omitempty"`
+}
+
+func (x *CreateHeroRequest) Reset() {
+	*x = CreateHeroRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_heroes_v1_heroes_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *CreateHeroRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateHeroRequest) ProtoMessage() {}
+
+func (x *CreateHeroRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_heroes_v1_heroes_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateHeroRequest.ProtoReflect.Descriptor instead.
+func (*CreateHeroRequest) Descriptor() ([]byte, []int) {
+	return file_heroes_v1_heroes_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreateHeroRequest) GetHero() *Hero {
+	if x != nil {
+		return x.Hero
+	}
+	return nil
+}
+
+type DeleteHeroRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+}
+
+func (x *DeleteHeroRequest) Reset() {
+	*x = DeleteHeroRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_heroes_v1_heroes_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteHeroRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteHeroRequest) ProtoMessage() {}
+
+func (x *DeleteHeroRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_heroes_v1_heroes_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteHeroRequest.ProtoReflect.Descriptor instead.
+func (*DeleteHeroRequest) Descriptor() ([]byte, []int) {
+	return file_heroes_v1_heroes_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DeleteHeroRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteHeroResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *DeleteHeroResponse) Reset() {
+	*x = DeleteHeroResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_heroes_v1_heroes_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *DeleteHeroResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteHeroResponse) ProtoMessage() {}
+
+func (x *DeleteHeroResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_heroes_v1_heroes_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteHeroResponse.ProtoReflect.Descriptor instead.
+func (*DeleteHeroResponse) Descriptor() ([]byte, []int) {
+	return file_heroes_v1_heroes_proto_rawDescGZIP(), []int{5}
+}
+
+type StatusRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_heroes_v1_heroes_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_heroes_v1_heroes_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_heroes_v1_heroes_proto_rawDescGZIP(), []int{6}
+}
+
+type StatusResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Redis string `protobuf:"bytes,1,opt,name=redis,proto3" json:"redis,omitempty"`
+}
+
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_heroes_v1_heroes_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse) ProtoMessage() {}
+
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_heroes_v1_heroes_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_heroes_v1_heroes_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *StatusResponse) GetRedis() string {
+	if x != nil {
+		return x.Redis
+	}
+	return ""
+}
+
+var File_heroes_v1_heroes_proto protoreflect.FileDescriptor
+
+var file_heroes_v1_heroes_proto_rawDesc = []byte{
+	0x0a, 0x16, 0x68, 0x65, 0x72, 0x6f, 0x65, 0x73, 0x2f, 0x76, 0x31, 0x2f, 0x68, 0x65, 0x72, 0x6f,
+	0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x09, 0x68, 0x65, 0x72, 0x6f, 0x65, 0x73,
+	0x2e, 0x76, 0x31, 0x22, 0x2a, 0x0a, 0x04, 0x48, 0x65, 0x72, 0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x69,
+	0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69, 0x64, 0x12, 0x12, 0x0a, 0x04, 0x6e,
+	0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x6e, 0x61, 0x6d, 0x65, 0x22,
+	0x20, 0x0a, 0x0e, 0x47, 0x65, 0x74, 0x48, 0x65, 0x72, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x69,
+	0x64, 0x22, 0x13, 0x0a, 0x11, 0x4c, 0x69, 0x73, 0x74, 0x48, 0x65, 0x72, 0x6f, 0x65, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x38, 0x0a, 0x11, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65,
+	0x48, 0x65, 0x72, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x23, 0x0a, 0x04, 0x68,
+	0x65, 0x72, 0x6f, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x0f, 0x2e, 0x68, 0x65, 0x72, 0x6f,
+	0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x65, 0x72, 0x6f, 0x52, 0x04, 0x68, 0x65, 0x72, 0x6f,
+	0x22, 0x23, 0x0a, 0x11, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x48, 0x65, 0x72, 0x6f, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x02, 0x69, 0x64, 0x22, 0x14, 0x0a, 0x12, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x48,
+	0x65, 0x72, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x0f, 0x0a, 0x0d, 0x53,
+	0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x26, 0x0a, 0x0e,
+	0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x14,
+	0x0a, 0x05, 0x72, 0x65, 0x64, 0x69, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x72,
+	0x65, 0x64, 0x69, 0x73, 0x32, 0xca, 0x02, 0x0a, 0x0b, 0x48, 0x65, 0x72, 0x6f, 0x53, 0x65, 0x72,
+	0x76, 0x69, 0x63, 0x65, 0x12, 0x35, 0x0a, 0x07, 0x47, 0x65, 0x74, 0x48, 0x65, 0x72, 0x6f, 0x12,
+	0x19, 0x2e, 0x68, 0x65, 0x72, 0x6f, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x48,
+	0x65, 0x72, 0x6f, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x68, 0x65, 0x72,
+	0x6f, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x48, 0x65, 0x72, 0x6f, 0x12, 0x3d, 0x0a, 0x0a, 0x4c,
+	0x69, 0x73, 0x74, 0x48, 0x65, 0x72, 0x6f, 0x65, 0x73, 0x12, 0x1c, 0x2e, 0x68, 0x65, 0x72, 0x6f,
+	0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x48, 0x65, 0x72, 0x6f, 0x65, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x68, 0x65, 0x72, 0x6f, 0x65, 0x73,
+	0x2e, 0x76, 0x31, 0x2e, 0x48, 0x65, 0x72, 0x6f, 0x30, 0x01, 0x12, 0x3b, 0x0a, 0x0a, 0x43, 0x72,
+	0x65, 0x61, 0x74, 0x65, 0x48, 0x65, 0x72, 0x6f, 0x12, 0x1c, 0x2e, 0x68, 0x65, 0x72, 0x6f, 0x65,
+	0x73, 0x2e, 0x76, 0x31, 0x2e, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x48, 0x65, 0x72, 0x6f, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x0f, 0x2e, 0x68, 0x65, 0x72, 0x6f, 0x65, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x48, 0x65, 0x72, 0x6f, 0x12, 0x49, 0x0a, 0x0a, 0x44, 0x65, 0x6c, 0x65, 0x74,
+	0x65, 0x48, 0x65, 0x72, 0x6f, 0x12, 0x1c, 0x2e, 0x68, 0x65, 0x72, 0x6f, 0x65, 0x73, 0x2e, 0x76,
+	0x31, 0x2e, 0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x48, 0x65, 0x72, 0x6f, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x1a, 0x1d, 0x2e, 0x68, 0x65, 0x72, 0x6f, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e,
+	0x44, 0x65, 0x6c, 0x65, 0x74, 0x65, 0x48, 0x65, 0x72, 0x6f, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e,
+	0x73, 0x65, 0x12, 0x3d, 0x0a, 0x06, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x12, 0x18, 0x2e, 0x68,
+	0x65, 0x72, 0x6f, 0x65, 0x73, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x19, 0x2e, 0x68, 0x65, 0x72, 0x6f, 0x65, 0x73, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x75, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x42, 0x39, 0x5a, 0x37, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x62, 0x6c, 0x69, 0x75, 0x63, 0x68, 0x61, 0x6b, 0x2f, 0x68, 0x65, 0x72, 0x6f, 0x65, 0x73, 0x2f,
+	0x69, 0x6e, 0x74, 0x65, 0x72, 0x6e, 0x61, 0x6c, 0x2f, 0x67, 0x72, 0x70, 0x63, 0x73, 0x65, 0x72,
+	0x76, 0x65, 0x72, 0x2f, 0x68, 0x65, 0x72, 0x6f, 0x65, 0x73, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_heroes_v1_heroes_proto_rawDescOnce sync.Once
+	file_heroes_v1_heroes_proto_rawDescData = file_heroes_v1_heroes_proto_rawDesc
+)
+
+func file_heroes_v1_heroes_proto_rawDescGZIP() []byte {
+	file_heroes_v1_heroes_proto_rawDescOnce.Do(func() {
+		file_heroes_v1_heroes_proto_rawDescData = protoimpl.X.CompressGZIP(file_heroes_v1_heroes_proto_rawDescData)
+	})
+	return file_heroes_v1_heroes_proto_rawDescData
+}
+
+var file_heroes_v1_heroes_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_heroes_v1_heroes_proto_goTypes = []any{
+	(*Hero)(nil),               // 0: heroes.v1.Hero
+	(*GetHeroRequest)(nil),     // 1: heroes.v1.GetHeroRequest
+	(*ListHeroesRequest)(nil),  // 2: heroes.v1.ListHeroesRequest
+	(*CreateHeroRequest)(nil),  // 3: heroes.v1.CreateHeroRequest
+	(*DeleteHeroRequest)(nil),  // 4: heroes.v1.DeleteHeroRequest
+	(*DeleteHeroResponse)(nil), // 5: heroes.v1.DeleteHeroResponse
+	(*StatusRequest)(nil),      // 6: heroes.v1.StatusRequest
+	(*StatusResponse)(nil),     // 7: heroes.v1.StatusResponse
+}
+var file_heroes_v1_heroes_proto_depIdxs = []int32{
+	0, // 0: heroes.v1.CreateHeroRequest.hero:type_name -> heroes.v1.Hero
+	1, // 1: heroes.v1.HeroService.GetHero:input_type -> heroes.v1.GetHeroRequest
+	2, // 2: heroes.v1.HeroService.ListHeroes:input_type -> heroes.v1.ListHeroesRequest
+	3, // 3: heroes.v1.HeroService.CreateHero:input_type -> heroes.v1.CreateHeroRequest
+	4, // 4: heroes.v1.HeroService.DeleteHero:input_type -> heroes.v1.DeleteHeroRequest
+	6, // 5: heroes.v1.HeroService.Status:input_type -> heroes.v1.StatusRequest
+	0, // 6: heroes.v1.HeroService.GetHero:output_type -> heroes.v1.Hero
+	0, // 7: heroes.v1.HeroService.ListHeroes:output_type -> heroes.v1.Hero
+	0, // 8: heroes.v1.HeroService.CreateHero:output_type -> heroes.v1.Hero
+	5, // 9: heroes.v1.HeroService.DeleteHero:output_type -> heroes.v1.DeleteHeroResponse
+	7, // 10: heroes.v1.HeroService.Status:output_type -> heroes.v1.StatusResponse
+	6, // [6:11] is the sub-list for method output_type
+	1, // [1:6] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_heroes_v1_heroes_proto_init() }
+func file_heroes_v1_heroes_proto_init() {
+	if File_heroes_v1_heroes_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_heroes_v1_heroes_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Hero); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_heroes_v1_heroes_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*GetHeroRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_heroes_v1_heroes_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*ListHeroesRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_heroes_v1_heroes_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*CreateHeroRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_heroes_v1_heroes_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*DeleteHeroRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_heroes_v1_heroes_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*DeleteHeroResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_heroes_v1_heroes_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*StatusRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_heroes_v1_heroes_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*StatusResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_heroes_v1_heroes_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_heroes_v1_heroes_proto_goTypes,
+		DependencyIndexes: file_heroes_v1_heroes_proto_depIdxs,
+		MessageInfos:      file_heroes_v1_heroes_proto_msgTypes,
+	}.Build()
+	File_heroes_v1_heroes_proto = out.File
+	file_heroes_v1_heroes_proto_rawDesc = nil
+	file_heroes_v1_heroes_proto_goTypes = nil
+	file_heroes_v1_heroes_proto_depIdxs = nil
+}
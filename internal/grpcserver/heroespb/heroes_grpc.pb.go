@@ -0,0 +1,304 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             (unknown)
+// source: heroes/v1/heroes.proto
+
+package heroespb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	HeroService_GetHero_FullMethodName    = "/heroes.v1.HeroService/GetHero"
+	HeroService_ListHeroes_FullMethodName = "/heroes.v1.HeroService/ListHeroes"
+	HeroService_CreateHero_FullMethodName = "/heroes.v1.HeroService/CreateHero"
+	HeroService_DeleteHero_FullMethodName = "/heroes.v1.HeroService/DeleteHero"
+	HeroService_Status_FullMethodName     = "/heroes.v1.HeroService/Status"
+)
+
+// HeroServiceClient is the client API for HeroService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// HeroService provides basic CRUD functionality about superheroes
+type HeroServiceClient interface {
+	// GetHero returns single hero, NOT_FOUND if hero doesn't exist
+	GetHero(ctx context.Context, in *GetHeroRequest, opts ...grpc.CallOption) (*Hero, error)
+	// ListHeroes streams all heroes
+	ListHeroes(ctx context.Context, in *ListHeroesRequest, opts ...grpc.CallOption) (HeroService_ListHeroesClient, error)
+	// CreateHero creates new hero or overwrites existing one
+	CreateHero(ctx context.Context, in *CreateHeroRequest, opts ...grpc.CallOption) (*Hero, error)
+	// DeleteHero deletes hero, NOT_FOUND if hero doesn't exist
+	DeleteHero(ctx context.Context, in *DeleteHeroRequest, opts ...grpc.CallOption) (*DeleteHeroResponse, error)
+	// Status returns status of application dependencies
+	Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error)
+}
+
+type heroServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewHeroServiceClient(cc grpc.ClientConnInterface) HeroServiceClient {
+	return &heroServiceClient{cc}
+}
+
+func (c *heroServiceClient) GetHero(ctx context.Context, in *GetHeroRequest, opts ...grpc.CallOption) (*Hero, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Hero)
+	err := c.cc.Invoke(ctx, HeroService_GetHero_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *heroServiceClient) ListHeroes(ctx context.Context, in *ListHeroesRequest, opts ...grpc.CallOption) (HeroService_ListHeroesClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &HeroService_ServiceDesc.Streams[0], HeroService_ListHeroes_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &heroServiceListHeroesClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type HeroService_ListHeroesClient interface {
+	Recv() (*Hero, error)
+	grpc.ClientStream
+}
+
+type heroServiceListHeroesClient struct {
+	grpc.ClientStream
+}
+
+func (x *heroServiceListHeroesClient) Recv() (*Hero, error) {
+	m := new(Hero)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *heroServiceClient) CreateHero(ctx context.Context, in *CreateHeroRequest, opts ...grpc.CallOption) (*Hero, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Hero)
+	err := c.cc.Invoke(ctx, HeroService_CreateHero_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *heroServiceClient) DeleteHero(ctx context.Context, in *DeleteHeroRequest, opts ...grpc.CallOption) (*DeleteHeroResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteHeroResponse)
+	err := c.cc.Invoke(ctx, HeroService_DeleteHero_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *heroServiceClient) Status(ctx context.Context, in *StatusRequest, opts ...grpc.CallOption) (*StatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(StatusResponse)
+	err := c.cc.Invoke(ctx, HeroService_Status_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// HeroServiceServer is the server API for HeroService service.
+// All implementations must embed UnimplementedHeroServiceServer
+// for forward compatibility
+//
+// HeroService provides basic CRUD functionality about superheroes
+type HeroServiceServer interface {
+	// GetHero returns single hero, NOT_FOUND if hero doesn't exist
+	GetHero(context.Context, *GetHeroRequest) (*Hero, error)
+	// ListHeroes streams all heroes
+	ListHeroes(*ListHeroesRequest, HeroService_ListHeroesServer) error
+	// CreateHero creates new hero or overwrites existing one
+	CreateHero(context.Context, *CreateHeroRequest) (*Hero, error)
+	// DeleteHero deletes hero, NOT_FOUND if hero doesn't exist
+	DeleteHero(context.Context, *DeleteHeroRequest) (*DeleteHeroResponse, error)
+	// Status returns status of application dependencies
+	Status(context.Context, *StatusRequest) (*StatusResponse, error)
+	mustEmbedUnimplementedHeroServiceServer()
+}
+
+// UnimplementedHeroServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedHeroServiceServer struct {
+}
+
+func (UnimplementedHeroServiceServer) GetHero(context.Context, *GetHeroRequest) (*Hero, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHero not implemented")
+}
+func (UnimplementedHeroServiceServer) ListHeroes(*ListHeroesRequest, HeroService_ListHeroesServer) error {
+	return status.Errorf(codes.Unimplemented, "method ListHeroes not implemented")
+}
+func (UnimplementedHeroServiceServer) CreateHero(context.Context, *CreateHeroRequest) (*Hero, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateHero not implemented")
+}
+func (UnimplementedHeroServiceServer) DeleteHero(context.Context, *DeleteHeroRequest) (*DeleteHeroResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteHero not implemented")
+}
+func (UnimplementedHeroServiceServer) Status(context.Context, *StatusRequest) (*StatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Status not implemented")
+}
+func (UnimplementedHeroServiceServer) mustEmbedUnimplementedHeroServiceServer() {}
+
+// UnsafeHeroServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to HeroServiceServer will
+// result in compilation errors.
+type UnsafeHeroServiceServer interface {
+	mustEmbedUnimplementedHeroServiceServer()
+}
+
+func RegisterHeroServiceServer(s grpc.ServiceRegistrar, srv HeroServiceServer) {
+	s.RegisterService(&HeroService_ServiceDesc, srv)
+}
+
+func _HeroService_GetHero_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetHeroRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HeroServiceServer).GetHero(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HeroService_GetHero_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HeroServiceServer).GetHero(ctx, req.(*GetHeroRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HeroService_ListHeroes_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListHeroesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(HeroServiceServer).ListHeroes(m, &heroServiceListHeroesServer{ServerStream: stream})
+}
+
+type HeroService_ListHeroesServer interface {
+	Send(*Hero) error
+	grpc.ServerStream
+}
+
+type heroServiceListHeroesServer struct {
+	grpc.ServerStream
+}
+
+func (x *heroServiceListHeroesServer) Send(m *Hero) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _HeroService_CreateHero_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateHeroRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HeroServiceServer).CreateHero(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HeroService_CreateHero_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HeroServiceServer).CreateHero(ctx, req.(*CreateHeroRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HeroService_DeleteHero_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteHeroRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HeroServiceServer).DeleteHero(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HeroService_DeleteHero_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HeroServiceServer).DeleteHero(ctx, req.(*DeleteHeroRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HeroService_Status_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HeroServiceServer).Status(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: HeroService_Status_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HeroServiceServer).Status(ctx, req.(*StatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// HeroService_ServiceDesc is the grpc.ServiceDesc for HeroService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var HeroService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "heroes.v1.HeroService",
+	HandlerType: (*HeroServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetHero",
+			Handler:    _HeroService_GetHero_Handler,
+		},
+		{
+			MethodName: "CreateHero",
+			Handler:    _HeroService_CreateHero_Handler,
+		},
+		{
+			MethodName: "DeleteHero",
+			Handler:    _HeroService_DeleteHero_Handler,
+		},
+		{
+			MethodName: "Status",
+			Handler:    _HeroService_Status_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListHeroes",
+			Handler:       _HeroService_ListHeroes_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "heroes/v1/heroes.proto",
+}
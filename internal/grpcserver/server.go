@@ -0,0 +1,199 @@
+package grpcserver
+
+//go:generate sh -c "cd ../.. && buf generate"
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"strconv"
+
+	"github.com/bliuchak/heroes/internal/apierror"
+	"github.com/bliuchak/heroes/internal/auth"
+	"github.com/bliuchak/heroes/internal/config"
+	"github.com/bliuchak/heroes/internal/grpcserver/heroespb"
+	"github.com/bliuchak/heroes/internal/storage"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// validID has the same format as {id} in http routes
+var validID = regexp.MustCompile(`^[0-9]+$`)
+
+// Server is gRPC server which exposes HeroService
+type Server struct {
+	heroespb.UnimplementedHeroServiceServer
+
+	Storage storage.Storager
+	Logger  zerolog.Logger
+	Config  config.Config
+	// Keys keeps API keys, if un-set Storage is used when it's able to
+	Keys storage.KeyStorager
+	// JWT verifies bearer tokens, if un-set only API keys are accepted
+	JWT *auth.JWTVerifier
+
+	server *grpc.Server
+}
+
+// NewServer create pointer for new gRPC server structure
+func NewServer(storage storage.Storager, logger zerolog.Logger, config config.Config) *Server {
+	s := &Server{
+		Storage: storage,
+		Logger:  logger,
+		Config:  config,
+	}
+
+	s.server = grpc.NewServer(
+		grpc.ChainUnaryInterceptor(s.unaryLogger, s.unaryAuth),
+		grpc.ChainStreamInterceptor(s.streamLogger, s.streamAuth),
+	)
+	heroespb.RegisterHeroServiceServer(s.server, s)
+
+	return s
+}
+
+// Run runs gRPC server on configured port
+func (s *Server) Run() error {
+	lis, err := net.Listen("tcp", ":"+strconv.Itoa(s.Config.GRPC.Port))
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(lis)
+}
+
+// Serve accepts connections on given listener
+func (s *Server) Serve(lis net.Listener) error {
+	err := s.server.Serve(lis)
+	if err != nil && err != grpc.ErrServerStopped {
+		return err
+	}
+	return nil
+}
+
+// Shutdown stops accepting new connections and waits until in-flight calls
+// are finished, calls which are still running when ctx is done are canceled
+func (s *Server) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// Close stops gRPC server and closes all connections
+func (s *Server) Close() error {
+	s.server.Stop()
+	return nil
+}
+
+// GetHero gets single hero
+func (s *Server) GetHero(ctx context.Context, req *heroespb.GetHeroRequest) (*heroespb.Hero, error) {
+	if !validID.MatchString(req.GetId()) {
+		return nil, status.Error(codes.InvalidArgument, "invalid hero id")
+	}
+
+	h, err := s.Storage.GetHero(ctx, req.GetId())
+	if err != nil {
+		return nil, s.toStatus(err, "Unable to get hero")
+	}
+
+	return &heroespb.Hero{Id: h.ID, Name: h.Name}, nil
+}
+
+// ListHeroes streams all heroes
+func (s *Server) ListHeroes(_ *heroespb.ListHeroesRequest, stream heroespb.HeroService_ListHeroesServer) error {
+	hs, err := s.Storage.GetHeroes(stream.Context())
+	if err != nil {
+		return s.toStatus(err, "Unable to get heroes")
+	}
+
+	for _, h := range hs {
+		if err := stream.Send(&heroespb.Hero{Id: h.ID, Name: h.Name}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateHero creates a new hero
+func (s *Server) CreateHero(ctx context.Context, req *heroespb.CreateHeroRequest) (*heroespb.Hero, error) {
+	hero := storage.Hero{ID: req.GetHero().GetId(), Name: req.GetHero().GetName()}
+	if !hero.IsValid() || !validID.MatchString(hero.ID) {
+		return nil, status.Error(codes.InvalidArgument, "hero is not valid")
+	}
+
+	if err := s.Storage.CreateHero(ctx, hero.ID, hero.Name, auth.OwnerOf(ctx)); err != nil {
+		return nil, s.toStatus(err, "Unable to send create hero request")
+	}
+
+	return &heroespb.Hero{Id: hero.ID, Name: hero.Name}, nil
+}
+
+// DeleteHero deletes hero
+func (s *Server) DeleteHero(ctx context.Context, req *heroespb.DeleteHeroRequest) (*heroespb.DeleteHeroResponse, error) {
+	if !validID.MatchString(req.GetId()) {
+		return nil, status.Error(codes.InvalidArgument, "invalid hero id")
+	}
+
+	if err := s.Storage.DeleteHero(ctx, req.GetId(), auth.OwnerOf(ctx)); err != nil {
+		return nil, s.toStatus(err, "Unable to delete hero")
+	}
+
+	return &heroespb.DeleteHeroResponse{}, nil
+}
+
+// Status returns status of application dependencies
+func (s *Server) Status(ctx context.Context, _ *heroespb.StatusRequest) (*heroespb.StatusResponse, error) {
+	st, err := s.Storage.Status(ctx)
+	if err != nil {
+		s.Logger.Error().Err(err).Msg("Unable to get storage status")
+		return nil, status.Error(codes.Unavailable, "storage is unavailable")
+	}
+
+	return &heroespb.StatusResponse{Redis: st}, nil
+}
+
+// codesOf maps storage error kinds to gRPC codes, like problem.FromError
+// maps them to response codes
+var codesOf = map[storage.Kind]codes.Code{
+	storage.ErrNotFound:           codes.NotFound,
+	storage.ErrConflict:           codes.Aborted,
+	storage.ErrPreconditionFailed: codes.FailedPrecondition,
+	storage.ErrInvalid:            codes.InvalidArgument,
+	storage.ErrForbidden:          codes.PermissionDenied,
+	storage.ErrUnavailable:        codes.Unavailable,
+	storage.ErrInternal:           codes.Internal,
+}
+
+// toStatus maps storage errors to gRPC status, unexpected errors are logged
+func (s *Server) toStatus(err error, msg string) error {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	e := apierror.Log(&s.Logger, err, msg)
+	return status.Error(codesOf[e.Kind], e.Message)
+}
+
+func (s *Server) unaryLogger(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	s.Logger.Info().Str("method", info.FullMethod).Msg("gRPC call")
+	return handler(ctx, req)
+}
+
+func (s *Server) streamLogger(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	s.Logger.Info().Str("method", info.FullMethod).Msg("gRPC stream")
+	return handler(srv, ss)
+}
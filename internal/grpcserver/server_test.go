@@ -0,0 +1,233 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/auth"
+	"github.com/bliuchak/heroes/internal/config"
+	"github.com/bliuchak/heroes/internal/grpcserver/heroespb"
+	"github.com/bliuchak/heroes/internal/storage"
+	stmocks "github.com/bliuchak/heroes/internal/storage/mocks"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestClient runs server with given storage on in-memory listener
+func newTestClient(t *testing.T, s storage.Storager) heroespb.HeroServiceClient {
+	return dial(t, NewServer(s, zerolog.Nop(), config.Config{}))
+}
+
+// dial runs srv on in-memory listener and returns its client
+func dial(t *testing.T, srv *Server) heroespb.HeroServiceClient {
+	lis := bufconn.Listen(1024 * 1024)
+	go srv.Serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		conn.Close()
+		srv.Close()
+	})
+
+	return heroespb.NewHeroServiceClient(conn)
+}
+
+func TestServer_GetHero(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		storage  []interface{}
+		expected codes.Code
+	}{
+		{
+			name:     "should return invalid argument",
+			id:       "abc",
+			expected: codes.InvalidArgument,
+		},
+		{
+			name:     "should return not found",
+			id:       "1",
+			storage:  []interface{}{storage.Hero{}, storage.NewError(storage.ErrNotFound, "GetHero", "1", nil)},
+			expected: codes.NotFound,
+		},
+		{
+			name:     "should hide driver message",
+			id:       "1",
+			storage:  []interface{}{storage.Hero{}, storage.NewError(storage.ErrConflict, "GetHero", "1", errors.New("WRONGTYPE"))},
+			expected: codes.Aborted,
+		},
+		{
+			name:     "should return internal error",
+			id:       "1",
+			storage:  []interface{}{storage.Hero{}, errors.New("GET error")},
+			expected: codes.Internal,
+		},
+		{
+			name:     "should return hero",
+			id:       "1",
+			storage:  []interface{}{storage.Hero{ID: "1", Name: "Batman"}, nil},
+			expected: codes.OK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := new(stmocks.Storager)
+			if tt.storage != nil {
+				s.On("GetHero", mock.Anything, tt.id).Return(tt.storage...)
+			}
+
+			h, err := newTestClient(t, s).GetHero(context.Background(), &heroespb.GetHeroRequest{Id: tt.id})
+
+			assert.Equal(t, tt.expected, status.Code(err))
+			assert.NotContains(t, status.Convert(err).Message(), "WRONGTYPE")
+			if tt.expected == codes.OK {
+				assert.Equal(t, "Batman", h.GetName())
+			}
+		})
+	}
+}
+
+func TestServer_ListHeroes(t *testing.T) {
+	s := new(stmocks.Storager)
+	s.On("GetHeroes", mock.Anything).Return([]storage.Hero{{ID: "1", Name: "Batman"}, {ID: "2", Name: "Superman"}}, nil)
+
+	stream, err := newTestClient(t, s).ListHeroes(context.Background(), &heroespb.ListHeroesRequest{})
+	assert.NoError(t, err)
+
+	var names []string
+	for {
+		h, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		names = append(names, h.GetName())
+	}
+
+	assert.Equal(t, []string{"Batman", "Superman"}, names)
+}
+
+func TestServer_CreateHero(t *testing.T) {
+	s := new(stmocks.Storager)
+	s.On("CreateHero", mock.Anything, "1", "Batman", auth.Anonymous.Owner()).Return(nil)
+
+	client := newTestClient(t, s)
+
+	_, err := client.CreateHero(context.Background(), &heroespb.CreateHeroRequest{Hero: &heroespb.Hero{Id: "1"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	h, err := client.CreateHero(context.Background(), &heroespb.CreateHeroRequest{Hero: &heroespb.Hero{Id: "1", Name: "Batman"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", h.GetId())
+}
+
+func TestServer_DeleteHero(t *testing.T) {
+	s := new(stmocks.Storager)
+	s.On("DeleteHero", mock.Anything, "1", mock.Anything).Return(storage.NewError(storage.ErrNotFound, "DeleteHero", "1", nil))
+	s.On("DeleteHero", mock.Anything, "2", mock.Anything).Return(nil)
+
+	client := newTestClient(t, s)
+
+	_, err := client.DeleteHero(context.Background(), &heroespb.DeleteHeroRequest{Id: "1"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.DeleteHero(context.Background(), &heroespb.DeleteHeroRequest{Id: "2"})
+	assert.NoError(t, err)
+}
+
+func TestServer_Status(t *testing.T) {
+	s := new(stmocks.Storager)
+	s.On("Status", mock.Anything).Return("", errors.New("PING error")).Once()
+	s.On("Status", mock.Anything).Return("PONG", nil).Once()
+
+	client := newTestClient(t, s)
+
+	_, err := client.Status(context.Background(), &heroespb.StatusRequest{})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+
+	resp, err := client.Status(context.Background(), &heroespb.StatusRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, "PONG", resp.GetRedis())
+}
+
+func TestServer_ShutdownDrainsCalls(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	s := new(stmocks.Storager)
+	s.On("GetHero", mock.Anything, "1").Return(storage.Hero{ID: "1", Name: "Batman"}, nil).
+		Run(func(mock.Arguments) {
+			close(started)
+			<-release
+		})
+
+	lis := bufconn.Listen(1024 * 1024)
+	srv := NewServer(s, zerolog.Nop(), config.Config{})
+	go srv.Serve(lis)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := heroespb.NewHeroServiceClient(conn)
+
+	type result struct {
+		hero *heroespb.Hero
+		err  error
+	}
+	inFlight := make(chan result)
+	go func() {
+		h, err := client.GetHero(context.Background(), &heroespb.GetHeroRequest{Id: "1"})
+		inFlight <- result{h, err}
+	}()
+
+	// wait until call reaches storage
+	<-started
+
+	stopped := make(chan error)
+	go func() {
+		stopped <- srv.Shutdown(context.Background())
+	}()
+
+	// listener is closed once shutdown started draining
+	assert.Eventually(t, func() bool {
+		conn, err := lis.Dial()
+		if err != nil {
+			return true
+		}
+		conn.Close()
+		return false
+	}, time.Second, time.Millisecond)
+	select {
+	case <-stopped:
+		t.Fatal("shutdown finished before in-flight call")
+	default:
+	}
+	close(release)
+
+	res := <-inFlight
+	assert.NoError(t, res.err)
+	assert.Equal(t, "Batman", res.hero.GetName())
+	assert.NoError(t, <-stopped)
+}
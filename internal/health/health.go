@@ -0,0 +1,134 @@
+// Package health checks external dependencies of application
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/storage"
+)
+
+// dependency states
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Dependency is external dependency checked by readiness probe
+type Dependency struct {
+	Name  string
+	Check func(ctx context.Context) error
+	// Stats returns usage of dependency (e.g. connection pool), optional
+	Stats func() map[string]uint64
+}
+
+// StorageDependency returns dependency which pings storage, stats of
+// storage (e.g. pool usage) are reported when storage provides them
+func StorageDependency(st storage.Storager) Dependency {
+	d := Dependency{
+		Name: "storage",
+		Check: func(ctx context.Context) error {
+			_, err := st.Status(ctx)
+			return err
+		},
+	}
+	if sr, ok := st.(storage.StatsReporter); ok {
+		d.Stats = sr.Stats
+	}
+	return d
+}
+
+// Result is state of single dependency
+type Result struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	// LastError is the latest failure of dependency, it's kept after
+	// dependency recovers so flapping is visible
+	LastError   string            `json:"last_error,omitempty"`
+	LastErrorAt *time.Time        `json:"last_error_at,omitempty"`
+	Stats       map[string]uint64 `json:"stats,omitempty"`
+}
+
+type failure struct {
+	err string
+	at  time.Time
+}
+
+// Monitor checks dependencies and remembers last error of each of them
+type Monitor struct {
+	deps []Dependency
+	// timeout limits every check, 0 means that only deadline of ctx is used
+	timeout time.Duration
+
+	mu   sync.Mutex
+	last map[string]failure
+}
+
+// NewMonitor returns pointer to Monitor
+func NewMonitor(timeout time.Duration, deps ...Dependency) *Monitor {
+	return &Monitor{
+		deps:    deps,
+		timeout: timeout,
+		last:    map[string]failure{},
+	}
+}
+
+// Check checks all dependencies concurrently and reports whether every
+// one of them is up
+func (m *Monitor) Check(ctx context.Context) (map[string]Result, bool) {
+	results := make([]Result, len(m.deps))
+
+	var wg sync.WaitGroup
+	wg.Add(len(m.deps))
+	for i, d := range m.deps {
+		go func(i int, d Dependency) {
+			defer wg.Done()
+			results[i] = m.check(ctx, d)
+		}(i, d)
+	}
+	wg.Wait()
+
+	up := true
+	byName := make(map[string]Result, len(m.deps))
+	for i, d := range m.deps {
+		byName[d.Name] = results[i]
+		if results[i].Status != StatusUp {
+			up = false
+		}
+	}
+	return byName, up
+}
+
+func (m *Monitor) check(ctx context.Context, d Dependency) Result {
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := d.Check(ctx)
+	res := Result{
+		Status:    StatusUp,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if d.Stats != nil {
+		res.Stats = d.Stats()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+		m.last[d.Name] = failure{err: res.Error, at: start}
+	}
+	if f, ok := m.last[d.Name]; ok {
+		at := f.at
+		res.LastError = f.err
+		res.LastErrorAt = &at
+	}
+	return res
+}
@@ -0,0 +1,62 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonitor_Check(t *testing.T) {
+	var fail error
+	m := NewMonitor(time.Second,
+		Dependency{
+			Name:  "storage",
+			Check: func(context.Context) error { return fail },
+			Stats: func() map[string]uint64 { return map[string]uint64{"pool_size": 10} },
+		},
+		Dependency{
+			Name:  "notifier",
+			Check: func(context.Context) error { return nil },
+		},
+	)
+
+	res, up := m.Check(context.Background())
+	assert.True(t, up)
+	assert.Equal(t, StatusUp, res["storage"].Status)
+	assert.Equal(t, map[string]uint64{"pool_size": 10}, res["storage"].Stats)
+	assert.Empty(t, res["storage"].LastError)
+
+	fail = errors.New("connection refused")
+	res, up = m.Check(context.Background())
+	assert.False(t, up)
+	assert.Equal(t, StatusDown, res["storage"].Status)
+	assert.Equal(t, "connection refused", res["storage"].Error)
+	assert.Equal(t, StatusUp, res["notifier"].Status)
+
+	// last error is kept after dependency recovers
+	fail = nil
+	res, up = m.Check(context.Background())
+	assert.True(t, up)
+	assert.Empty(t, res["storage"].Error)
+	assert.Equal(t, "connection refused", res["storage"].LastError)
+	assert.NotNil(t, res["storage"].LastErrorAt)
+	assert.Empty(t, res["notifier"].LastError)
+}
+
+func TestMonitor_CheckTimeout(t *testing.T) {
+	m := NewMonitor(20*time.Millisecond, Dependency{
+		Name: "storage",
+		Check: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+
+	res, up := m.Check(context.Background())
+	assert.False(t, up)
+	assert.Equal(t, context.DeadlineExceeded.Error(), res["storage"].Error)
+	assert.Less(t, res["storage"].LatencyMS, float64(time.Second.Milliseconds()))
+}
@@ -0,0 +1,150 @@
+// Package metrics collects Prometheus metrics of HTTP server and storage
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "heroes"
+
+// Metrics keeps collectors of application, every instance has own
+// registry, so instances don't clash in tests
+type Metrics struct {
+	registry *prometheus.Registry
+
+	// HTTPRequests counts served requests by route, method and status
+	HTTPRequests *prometheus.CounterVec
+	// HTTPDuration observes latency of requests by route, method and status
+	HTTPDuration *prometheus.HistogramVec
+	// HTTPInFlight is number of requests which are being served
+	HTTPInFlight prometheus.Gauge
+	// HTTPPanics counts handlers which panicked
+	HTTPPanics prometheus.Counter
+	// StorageDuration observes latency of storage calls by method
+	StorageDuration *prometheus.HistogramVec
+	// StorageErrors counts failed storage calls by method and kind of error
+	StorageErrors *prometheus.CounterVec
+}
+
+// New returns pointer to Metrics with registered collectors of HTTP server,
+// storage, Go runtime and process
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		HTTPRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of served HTTP requests.",
+		}, []string{"route", "method", "status"}),
+		HTTPDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of HTTP requests.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method", "status"}),
+		HTTPInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_in_flight",
+			Help:      "Number of HTTP requests which are being served.",
+		}),
+		HTTPPanics: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "panics_total",
+			Help:      "Number of HTTP handlers which panicked.",
+		}),
+		StorageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "operation_duration_seconds",
+			Help:      "Latency of storage operations.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+		}, []string{"method"}),
+		StorageErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "operation_errors_total",
+			Help:      "Number of failed storage operations.",
+		}, []string{"method", "kind"}),
+	}
+
+	m.registry.MustRegister(
+		m.HTTPRequests, m.HTTPDuration, m.HTTPInFlight, m.HTTPPanics,
+		m.StorageDuration, m.StorageErrors,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Handler serves metrics in Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Register registers additional collectors
+func (m *Metrics) Register(cs ...prometheus.Collector) {
+	m.registry.MustRegister(cs...)
+}
+
+// RegisterPoolStats registers gauges of connection pool, stats returns
+// pool_size, pool_idle and pool_in_use like db.Redis does
+func (m *Metrics) RegisterPoolStats(stats func() map[string]uint64) {
+	gauge := func(name, help, key string) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      name,
+			Help:      help,
+		}, func() float64 {
+			return float64(stats()[key])
+		})
+	}
+
+	m.Register(
+		gauge("pool_size", "Max number of connections kept by storage pool.", "pool_size"),
+		gauge("pool_idle_connections", "Number of idle connections of storage pool.", "pool_idle"),
+		gauge("pool_in_use_connections", "Number of connections of storage pool which are in use.", "pool_in_use"),
+	)
+}
+
+// RegisterHeroCount registers gauge of number of stored heroes, counting
+// may scan whole storage, so count is refreshed at most once per interval
+// and the last known one is reported meanwhile
+func (m *Metrics) RegisterHeroCount(count func(ctx context.Context) (int, error), interval time.Duration) {
+	var (
+		mu      sync.Mutex
+		heroes  float64
+		counted time.Time
+	)
+
+	m.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "heroes",
+		Help:      "Number of stored heroes.",
+	}, func() float64 {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if time.Since(counted) < interval {
+			return heroes
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if n, err := count(ctx); err == nil {
+			heroes = float64(n)
+			counted = time.Now()
+		}
+		return heroes
+	}))
+}
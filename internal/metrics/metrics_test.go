@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_Handler(t *testing.T) {
+	m := New()
+	m.HTTPRequests.WithLabelValues("/v1/hero/{id}", http.MethodGet, "200").Inc()
+	m.RegisterPoolStats(func() map[string]uint64 {
+		return map[string]uint64{"pool_size": 10, "pool_idle": 7, "pool_in_use": 3}
+	})
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, rr.Body.String(), `heroes_http_requests_total{method="GET",route="/v1/hero/{id}",status="200"} 1`)
+	assert.Contains(t, rr.Body.String(), "heroes_storage_pool_in_use_connections 3")
+	assert.Contains(t, rr.Body.String(), "go_goroutines")
+}
+
+func TestMetrics_RegisterHeroCount(t *testing.T) {
+	m := New()
+
+	calls := 0
+	var err error
+	m.RegisterHeroCount(func(ctx context.Context) (int, error) {
+		calls++
+		return 42, err
+	}, time.Hour)
+
+	assert.NoError(t, testutil.GatherAndCompare(m.registry, heroes(42), "heroes_heroes"))
+	assert.NoError(t, testutil.GatherAndCompare(m.registry, heroes(42), "heroes_heroes"))
+	assert.Equal(t, 1, calls)
+
+	// failed count keeps the last known one
+	m = New()
+	err = errors.New("connection refused")
+	m.RegisterHeroCount(func(ctx context.Context) (int, error) {
+		return 0, err
+	}, 0)
+	assert.NoError(t, testutil.GatherAndCompare(m.registry, heroes(0), "heroes_heroes"))
+}
+
+// heroes returns exposition of hero count gauge
+func heroes(n int) io.Reader {
+	return strings.NewReader("# HELP heroes_heroes Number of stored heroes.\n# TYPE heroes_heroes gauge\nheroes_heroes " + strconv.Itoa(n) + "\n")
+}
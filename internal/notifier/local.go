@@ -0,0 +1,41 @@
+package notifier
+
+import "sync"
+
+// Local is an in-process notifier, it delivers events only to subscribers
+// of the same instance. Useful for single-node setup.
+type Local struct {
+	mu       sync.RWMutex
+	handlers []func(Event)
+}
+
+// NewLocal returns pointer to empty Local notifier
+func NewLocal() *Local {
+	return &Local{}
+}
+
+// Publish synchronously delivers event to every subscriber
+func (l *Local) Publish(e Event) {
+	l.mu.RLock()
+	handlers := l.handlers
+	l.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(e)
+	}
+}
+
+// Subscribe adds handler which will be called for every published event
+func (l *Local) Subscribe(handler func(Event)) {
+	l.mu.Lock()
+	l.handlers = append(l.handlers, handler)
+	l.mu.Unlock()
+}
+
+// Close removes all subscribers
+func (l *Local) Close() error {
+	l.mu.Lock()
+	l.handlers = nil
+	l.mu.Unlock()
+	return nil
+}
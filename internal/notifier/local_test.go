@@ -0,0 +1,26 @@
+package notifier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocal_Publish(t *testing.T) {
+	l := NewLocal()
+
+	var first, second []Event
+	l.Subscribe(func(e Event) { first = append(first, e) })
+	l.Subscribe(func(e Event) { second = append(second, e) })
+
+	e := Event{Op: OpCreate, ID: "1"}
+	l.Publish(e)
+
+	assert.Equal(t, []Event{e}, first)
+	assert.Equal(t, []Event{e}, second)
+
+	assert.NoError(t, l.Close())
+	l.Publish(Event{Op: OpDelete, ID: "1"})
+
+	assert.Equal(t, []Event{e}, first)
+}
@@ -0,0 +1,32 @@
+package notifier
+
+import "context"
+
+const (
+	// OpCreate tells that hero was created or overwritten
+	OpCreate = "create"
+	// OpUpdate tells that existing hero was updated
+	OpUpdate = "update"
+	// OpDelete tells that hero was deleted
+	OpDelete = "delete"
+)
+
+// Event describes single write operation made to storage
+type Event struct {
+	Op     string `json:"op"`
+	ID     string `json:"id"`
+	Origin string `json:"origin"`
+}
+
+// Notifier publishes storage write events and delivers them to subscribers
+// of every running instance
+type Notifier interface {
+	Publish(e Event)
+	Subscribe(handler func(Event))
+	Close() error
+}
+
+// Pinger is implemented by notifiers which depend on external service
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/rs/zerolog"
+)
+
+// Fallback limits requests with shared limiter and switches to fallback
+// limiter while shared one fails, e.g. when redis is unavailable. Switches
+// are logged once, not on every request.
+type Fallback struct {
+	shared   Limiter
+	fallback Limiter
+	logger   zerolog.Logger
+	degraded int32
+}
+
+// NewFallback returns pointer to Fallback limiter
+func NewFallback(shared, fallback Limiter, logger zerolog.Logger) *Fallback {
+	return &Fallback{shared: shared, fallback: fallback, logger: logger}
+}
+
+// Allow takes token from shared bucket, or from fallback one when shared
+// limiter fails
+func (f *Fallback) Allow(ctx context.Context, key string, l Limit) (Result, error) {
+	res, err := f.shared.Allow(ctx, key, l)
+	if err == nil {
+		if atomic.CompareAndSwapInt32(&f.degraded, 1, 0) {
+			f.logger.Info().Msg("Shared rate limiter is back")
+		}
+		return res, nil
+	}
+
+	// caller is gone, there is nothing to limit
+	if ctx.Err() != nil {
+		return res, err
+	}
+
+	if atomic.CompareAndSwapInt32(&f.degraded, 0, 1) {
+		f.logger.Warn().Err(err).Msg("Shared rate limiter failed, limiting requests locally")
+	}
+	return f.fallback.Allow(ctx, key, l)
+}
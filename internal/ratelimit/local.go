@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepEvery is how often buckets which are full again are forgotten
+const sweepEvery = time.Minute
+
+// Local keeps token buckets in memory, so limits apply to single instance
+// only. Useful for single-node setup and as fallback of shared limiter.
+type Local struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+
+	now func() time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// NewLocal returns pointer to Local limiter without buckets
+func NewLocal() *Local {
+	return &Local{
+		buckets: map[string]*bucket{},
+		now:     time.Now,
+	}
+}
+
+// Allow takes token from bucket of key, new bucket is full
+func (l *Local) Allow(_ context.Context, key string, limit Limit) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok || b.limit != limit {
+		b = &bucket{tokens: float64(limit.Requests), last: now, limit: limit}
+		l.buckets[key] = b
+	}
+	b.refill(now)
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return NewResult(allowed, b.tokens, limit), nil
+}
+
+// refill adds tokens for time passed since last request
+func (b *bucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last)
+	if elapsed <= 0 {
+		return
+	}
+	b.tokens += float64(b.limit.Requests) * float64(elapsed) / float64(b.limit.Window)
+	if full := float64(b.limit.Requests); b.tokens > full {
+		b.tokens = full
+	}
+	b.last = now
+}
+
+// sweep forgets buckets which are full, they are the same as new ones
+func (l *Local) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepEvery {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Requests) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		in       string
+		expected Limit
+		isError  bool
+	}{
+		{in: "100/1m", expected: Limit{Requests: 100, Window: time.Minute}},
+		{in: "10/s", expected: Limit{Requests: 10, Window: time.Second}},
+		{in: " 5/90s ", expected: Limit{Requests: 5, Window: 90 * time.Second}},
+		{in: "100", isError: true},
+		{in: "x/1m", isError: true},
+		{in: "10/0s", isError: true},
+		{in: "10/forever", isError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			l, err := ParseLimit(tt.in)
+			if tt.isError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, l)
+		})
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	limits, err := ParseLimits("GET /v1/heroes=10/1m, apikey:1a2b=1000/1m")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]Limit{
+		"GET /v1/heroes": {Requests: 10, Window: time.Minute},
+		"apikey:1a2b":    {Requests: 1000, Window: time.Minute},
+	}, limits)
+
+	limits, err = ParseLimits("")
+	assert.NoError(t, err)
+	assert.Empty(t, limits)
+
+	_, err = ParseLimits("GET /v1/heroes")
+	assert.Error(t, err)
+}
+
+func TestLocal_Allow(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := NewLocal()
+	l.now = func() time.Time { return now }
+	limit := Limit{Requests: 2, Window: 10 * time.Second}
+
+	res, _ := l.Allow(context.Background(), "a", limit)
+	assert.Equal(t, Result{Allowed: true, Limit: 2, Remaining: 1, Reset: 5 * time.Second}, res)
+
+	res, _ = l.Allow(context.Background(), "a", limit)
+	assert.Equal(t, Result{Allowed: true, Limit: 2, Remaining: 0, Reset: 10 * time.Second}, res)
+
+	res, _ = l.Allow(context.Background(), "a", limit)
+	assert.Equal(t, Result{Allowed: false, Limit: 2, Remaining: 0, Reset: 10 * time.Second, RetryAfter: 5 * time.Second}, res)
+
+	// other clients have own buckets
+	res, _ = l.Allow(context.Background(), "b", limit)
+	assert.True(t, res.Allowed)
+
+	// bucket is refilled evenly
+	now = now.Add(5 * time.Second)
+	res, _ = l.Allow(context.Background(), "a", limit)
+	assert.True(t, res.Allowed)
+	res, _ = l.Allow(context.Background(), "a", limit)
+	assert.False(t, res.Allowed)
+}
+
+func TestLocal_SweepsFullBuckets(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := NewLocal()
+	l.now = func() time.Time { return now }
+	limit := Limit{Requests: 1, Window: time.Second}
+
+	l.Allow(context.Background(), "a", limit)
+	now = now.Add(sweepEvery)
+	l.Allow(context.Background(), "b", limit)
+
+	assert.Len(t, l.buckets, 1)
+	assert.Contains(t, l.buckets, "b")
+}
+
+type failingLimiter struct{ err error }
+
+func (f failingLimiter) Allow(context.Context, string, Limit) (Result, error) {
+	return Result{}, f.err
+}
+
+func TestFallback_Allow(t *testing.T) {
+	limit := Limit{Requests: 1, Window: time.Minute}
+	shared := &failingLimiter{err: errors.New("connection refused")}
+	f := NewFallback(shared, NewLocal(), zerolog.Nop())
+
+	res, err := f.Allow(context.Background(), "a", limit)
+	assert.NoError(t, err)
+	assert.True(t, res.Allowed)
+	res, err = f.Allow(context.Background(), "a", limit)
+	assert.NoError(t, err)
+	assert.False(t, res.Allowed)
+
+	shared.err = nil
+	res, err = f.Allow(context.Background(), "a", limit)
+	assert.NoError(t, err)
+	assert.Equal(t, Result{}, res)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	shared.err = context.Canceled
+	_, err = f.Allow(ctx, "a", limit)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRules_Limit(t *testing.T) {
+	rules := Rules{
+		Default: Limit{Requests: 100, Window: time.Minute},
+		Routes:  map[string]Limit{"GET /v1/heroes": {Requests: 10, Window: time.Minute}},
+		Clients: map[string]Limit{"apikey:1": {Requests: 1000, Window: time.Minute}},
+	}
+
+	assert.Equal(t, 100, rules.Limit("GET /v1/hero/{id}", "apikey:2").Requests)
+	assert.Equal(t, 10, rules.Limit("GET /v1/heroes", "apikey:2").Requests)
+	assert.Equal(t, 1000, rules.Limit("GET /v1/heroes", "apikey:1").Requests)
+	assert.False(t, rules.IsZero())
+	assert.True(t, Rules{Routes: map[string]Limit{"GET /v1/heroes": {}}}.IsZero())
+}
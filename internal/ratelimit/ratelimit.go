@@ -0,0 +1,151 @@
+// Package ratelimit limits rate of requests made by clients with token
+// buckets, buckets are kept in memory or shared between instances
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limit allows burst of Requests which is refilled evenly during Window,
+// zero Limit doesn't limit anything
+type Limit struct {
+	Requests int
+	Window   time.Duration
+}
+
+// IsZero reports whether limit doesn't limit anything
+func (l Limit) IsZero() bool {
+	return l.Requests <= 0 || l.Window <= 0
+}
+
+// String formats limit the same way ParseLimit reads it, e.g. 100/1m0s
+func (l Limit) String() string {
+	return strconv.Itoa(l.Requests) + "/" + l.Window.String()
+}
+
+// ParseLimit parses limit in format <requests>/<window>, e.g. 100/1m,
+// window without number means one unit, e.g. 10/s
+func ParseLimit(s string) (Limit, error) {
+	reqs, window, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("limit %q is not in format <requests>/<window>", s)
+	}
+
+	n, err := strconv.Atoi(reqs)
+	if err != nil || n < 0 {
+		return Limit{}, fmt.Errorf("limit %q has invalid number of requests", s)
+	}
+
+	if window != "" && (window[0] < '0' || window[0] > '9') {
+		window = "1" + window
+	}
+	d, err := time.ParseDuration(window)
+	if err != nil || d <= 0 {
+		return Limit{}, fmt.Errorf("limit %q has invalid window", s)
+	}
+
+	return Limit{Requests: n, Window: d}, nil
+}
+
+// ParseLimits parses comma separated list of <name>=<limit>, e.g.
+// "GET /v1/heroes=10/1m,apikey:1a2b=1000/1m"
+func ParseLimits(s string) (map[string]Limit, error) {
+	limits := map[string]Limit{}
+	if strings.TrimSpace(s) == "" {
+		return limits, nil
+	}
+
+	for _, item := range strings.Split(s, ",") {
+		name, limit, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("limit %q is not in format <name>=<limit>", item)
+		}
+
+		l, err := ParseLimit(limit)
+		if err != nil {
+			return nil, err
+		}
+		limits[name] = l
+	}
+	return limits, nil
+}
+
+// Rules choose limit of client on route
+type Rules struct {
+	// Default applies to every client on every route
+	Default Limit
+	// Routes override Default, keys are method and route template,
+	// e.g. "GET /v1/heroes"
+	Routes map[string]Limit
+	// Clients override limits of routes, keys are client IDs,
+	// e.g. "apikey:1a2b" or "ip:10.0.0.1"
+	Clients map[string]Limit
+}
+
+// Limit returns limit of client on route
+func (r Rules) Limit(route, client string) Limit {
+	if l, ok := r.Clients[client]; ok {
+		return l
+	}
+	if l, ok := r.Routes[route]; ok {
+		return l
+	}
+	return r.Default
+}
+
+// IsZero reports whether rules don't limit anything
+func (r Rules) IsZero() bool {
+	if !r.Default.IsZero() {
+		return false
+	}
+	for _, l := range r.Routes {
+		if !l.IsZero() {
+			return false
+		}
+	}
+	for _, l := range r.Clients {
+		if !l.IsZero() {
+			return false
+		}
+	}
+	return true
+}
+
+// Result describes state of bucket after request was counted
+type Result struct {
+	Allowed bool
+	Limit   int
+	// Remaining is number of requests which are allowed right now
+	Remaining int
+	// Reset is time until bucket is full again
+	Reset time.Duration
+	// RetryAfter is time until next request is allowed, it's set only
+	// when request isn't allowed
+	RetryAfter time.Duration
+}
+
+// Limiter takes single token from bucket identified by key
+type Limiter interface {
+	Allow(ctx context.Context, key string, l Limit) (Result, error)
+}
+
+// NewResult computes result from number of tokens left in bucket
+func NewResult(allowed bool, tokens float64, l Limit) Result {
+	perToken := float64(l.Window) / float64(l.Requests)
+
+	res := Result{
+		Allowed:   allowed,
+		Limit:     l.Requests,
+		Remaining: int(tokens),
+		Reset:     time.Duration((float64(l.Requests) - tokens) * perToken),
+	}
+	if !allowed {
+		res.RetryAfter = time.Duration((1 - tokens) * perToken)
+	}
+	return res
+}
@@ -0,0 +1,378 @@
+package codec
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrUnsupportedValue is returned by codecs which can't represent given value,
+// e.g. CSV is able to encode lists only
+var ErrUnsupportedValue = errors.New("value can't be represented in requested media type")
+
+// Codec describes media type which API is able to read and write
+type Codec struct {
+	MediaType string
+	Marshal   func(v interface{}) ([]byte, error)
+	// Unmarshal is nil for codecs which are used for responses only
+	Unmarshal func(data []byte, v interface{}) error
+	// Decode decodes data into generic value (maps, slices, scalars)
+	// used to validate request body against schema
+	Decode func(data []byte) (interface{}, error)
+}
+
+// JSON codec, used by default
+var JSON = Codec{
+	MediaType: "application/json",
+	Marshal:   json.Marshal,
+	Unmarshal: json.Unmarshal,
+	Decode: func(data []byte) (interface{}, error) {
+		var v interface{}
+		err := json.Unmarshal(data, &v)
+		return v, err
+	},
+}
+
+// XML codec
+var XML = Codec{
+	MediaType: "application/xml",
+	Marshal:   marshalXML,
+	Unmarshal: xml.Unmarshal,
+	Decode:    decodeXML,
+}
+
+// MsgPack codec, struct fields are named after json tags
+var MsgPack = Codec{
+	MediaType: "application/msgpack",
+	Marshal:   marshalMsgPack,
+	Unmarshal: unmarshalMsgPack,
+	Decode: func(data []byte) (interface{}, error) {
+		var v interface{}
+		err := unmarshalMsgPack(data, &v)
+		return v, err
+	},
+}
+
+// YAML codec
+var YAML = Codec{
+	MediaType: "application/yaml",
+	Marshal:   yaml.Marshal,
+	Unmarshal: yaml.Unmarshal,
+	Decode: func(data []byte) (interface{}, error) {
+		var v interface{}
+		err := yaml.Unmarshal(data, &v)
+		return v, err
+	},
+}
+
+// CSV codec, able to encode lists of structs only
+var CSV = Codec{
+	MediaType: "text/csv",
+	Marshal:   marshalCSV,
+}
+
+// codecs in order of preference, used to resolve wildcards in Accept header
+var codecs = []Codec{JSON, XML, MsgPack, YAML, CSV}
+
+// aliases of media types which are widely used instead of registered ones
+var aliases = map[string]string{
+	"text/xml":                "application/xml",
+	"application/x-msgpack":   "application/msgpack",
+	"application/vnd.msgpack": "application/msgpack",
+	"application/x-yaml":      "application/yaml",
+	"text/yaml":               "application/yaml",
+}
+
+// MediaTypes returns all media types (including aliases) which codec accepts
+func MediaTypes(c Codec) []string {
+	mts := []string{c.MediaType}
+	for alias, mt := range aliases {
+		if mt == c.MediaType {
+			mts = append(mts, alias)
+		}
+	}
+	sort.Strings(mts[1:])
+	return mts
+}
+
+// Codecs returns all supported codecs in order of preference
+func Codecs() []Codec {
+	return append([]Codec(nil), codecs...)
+}
+
+// Lookup returns codec for given media type, parameters are ignored
+func Lookup(mediaType string) (Codec, bool) {
+	mt, _, err := mime.ParseMediaType(mediaType)
+	if err != nil {
+		return Codec{}, false
+	}
+	if canonical, ok := aliases[mt]; ok {
+		mt = canonical
+	}
+	for _, c := range codecs {
+		if c.MediaType == mt {
+			return c, true
+		}
+	}
+	return Codec{}, false
+}
+
+// ForContentType returns codec which is able to read request body of given content type
+// JSON is assumed when content type is empty
+func ForContentType(contentType string) (Codec, bool) {
+	if strings.TrimSpace(contentType) == "" {
+		return JSON, true
+	}
+	c, ok := Lookup(contentType)
+	if !ok || c.Unmarshal == nil {
+		return Codec{}, false
+	}
+	return c, true
+}
+
+// Negotiate returns codec for response which suits given Accept header best
+// JSON is used when header is empty or allows any media type
+func Negotiate(accept string) (Codec, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return JSON, true
+	}
+
+	for _, mr := range parseAccept(accept) {
+		switch {
+		case mr == "*/*":
+			return JSON, true
+		case strings.HasSuffix(mr, "/*"):
+			prefix := strings.TrimSuffix(mr, "*")
+			for _, c := range codecs {
+				if strings.HasPrefix(c.MediaType, prefix) {
+					return c, true
+				}
+			}
+		default:
+			if c, ok := Lookup(mr); ok {
+				return c, true
+			}
+		}
+	}
+
+	return Codec{}, false
+}
+
+type mediaRange struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept returns acceptable media ranges ordered by their quality
+func parseAccept(accept string) []string {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+
+		ranges = append(ranges, mediaRange{mediaType: mt, q: q})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].q > ranges[j].q
+	})
+
+	mts := make([]string, len(ranges))
+	for i, r := range ranges {
+		mts[i] = r.mediaType
+	}
+	return mts
+}
+
+// marshalXML encodes structs as elements named after their lowercased type
+// and slices as such elements wrapped into <items>
+func marshalXML(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := xml.NewEncoder(&buf)
+
+	rv := indirect(reflect.ValueOf(v))
+	if rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		start := xml.StartElement{Name: xml.Name{Local: "items"}}
+		if err := enc.EncodeToken(start); err != nil {
+			return nil, err
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if err := encodeXMLElement(enc, rv.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+		if err := enc.EncodeToken(start.End()); err != nil {
+			return nil, err
+		}
+	} else if err := encodeXMLElement(enc, rv); err != nil {
+		return nil, err
+	}
+
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeXMLElement(enc *xml.Encoder, v reflect.Value) error {
+	v = indirect(v)
+	if !v.IsValid() {
+		return ErrUnsupportedValue
+	}
+
+	name := strings.ToLower(v.Type().Name())
+	if name == "" {
+		name = "item"
+	}
+	return enc.EncodeElement(v.Interface(), xml.StartElement{Name: xml.Name{Local: name}})
+}
+
+// decodeXML decodes flat XML document into map of child element names to their text
+func decodeXML(data []byte) (interface{}, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	m := map[string]interface{}{}
+
+	depth := 0
+	var name string
+	var text strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) && depth == 0 {
+				return m, nil
+			}
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 {
+				name = t.Name.Local
+				text.Reset()
+			}
+			if depth > 2 {
+				return nil, fmt.Errorf("nested element %q is not supported", t.Name.Local)
+			}
+		case xml.CharData:
+			if depth == 2 {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			if depth == 2 {
+				m[name] = text.String()
+			}
+			depth--
+		}
+	}
+}
+
+func marshalMsgPack(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func unmarshalMsgPack(data []byte, v interface{}) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+// marshalCSV encodes list of structs as CSV with header row
+// columns are named after json tags of exported fields
+func marshalCSV(v interface{}) ([]byte, error) {
+	rv := indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, ErrUnsupportedValue
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	var header []string
+	for i := 0; i < rv.Len(); i++ {
+		el := indirect(rv.Index(i))
+		if el.Kind() != reflect.Struct {
+			return nil, ErrUnsupportedValue
+		}
+
+		var record []string
+		var names []string
+		for j := 0; j < el.NumField(); j++ {
+			f := el.Type().Field(j)
+			name := columnName(f)
+			if name == "" {
+				continue
+			}
+			names = append(names, name)
+			record = append(record, fmt.Sprint(el.Field(j).Interface()))
+		}
+
+		if header == nil {
+			header = names
+			if err := w.Write(header); err != nil {
+				return nil, err
+			}
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func columnName(f reflect.StructField) string {
+	if f.PkgPath != "" {
+		return ""
+	}
+	tag := strings.Split(f.Tag.Get("json"), ",")[0]
+	switch tag {
+	case "-":
+		return ""
+	case "":
+		return f.Name
+	}
+	return tag
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
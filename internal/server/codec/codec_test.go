@@ -0,0 +1,104 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/bliuchak/heroes/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name     string
+		accept   string
+		expected string
+		ok       bool
+	}{
+		{name: "should use json by default", accept: "", expected: "application/json", ok: true},
+		{name: "should use json for any type", accept: "*/*", expected: "application/json", ok: true},
+		{name: "should select exact type", accept: "application/xml", expected: "application/xml", ok: true},
+		{name: "should select alias", accept: "application/x-yaml", expected: "application/yaml", ok: true},
+		{name: "should resolve type wildcard", accept: "text/*", expected: "text/csv", ok: true},
+		{name: "should respect quality", accept: "application/json;q=0.5, application/msgpack", expected: "application/msgpack", ok: true},
+		{name: "should skip unsupported types", accept: "text/html, application/yaml;q=0.1", expected: "application/yaml", ok: true},
+		{name: "should skip not acceptable types", accept: "application/json;q=0", ok: false},
+		{name: "should fail on unsupported types", accept: "text/html, image/*", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ok := Negotiate(tt.accept)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, c.MediaType)
+		})
+	}
+}
+
+func TestForContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		expected    string
+		ok          bool
+	}{
+		{name: "should use json by default", contentType: "", expected: "application/json", ok: true},
+		{name: "should ignore parameters", contentType: "application/json; charset=utf-8", expected: "application/json", ok: true},
+		{name: "should select alias", contentType: "text/xml", expected: "application/xml", ok: true},
+		{name: "should fail on response only codec", contentType: "text/csv", ok: false},
+		{name: "should fail on unsupported type", contentType: "text/html", ok: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, ok := ForContentType(tt.contentType)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, c.MediaType)
+		})
+	}
+}
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	hero := storage.Hero{ID: "1", Name: "Batman"}
+
+	for _, c := range Codecs() {
+		if c.Unmarshal == nil {
+			continue
+		}
+		t.Run(c.MediaType, func(t *testing.T) {
+			data, err := c.Marshal(hero)
+			assert.NoError(t, err)
+
+			var got storage.Hero
+			assert.NoError(t, c.Unmarshal(data, &got))
+			assert.Equal(t, hero, got)
+
+			generic, err := c.Decode(data)
+			assert.NoError(t, err)
+			assert.EqualValues(t, map[string]interface{}{"id": "1", "name": "Batman"}, generic)
+		})
+	}
+}
+
+func TestXML_List(t *testing.T) {
+	data, err := XML.Marshal([]interface{}{
+		storage.Hero{ID: "1", Name: "Batman"},
+		storage.Hero{ID: "2", Name: "Superman"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`<items><hero><id>1</id><name>Batman</name></hero><hero><id>2</id><name>Superman</name></hero></items>`,
+		string(data))
+}
+
+func TestCSV(t *testing.T) {
+	data, err := CSV.Marshal([]interface{}{
+		storage.Hero{ID: "1", Name: "Batman"},
+		storage.Hero{ID: "2", Name: "Super, man"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "id,name,owner\n1,Batman,\n2,\"Super, man\",\n", string(data))
+
+	_, err = CSV.Marshal(storage.Hero{ID: "1", Name: "Batman"})
+	assert.Equal(t, ErrUnsupportedValue, err)
+
+	_, err = CSV.Marshal([]string{"Batman"})
+	assert.Equal(t, ErrUnsupportedValue, err)
+}
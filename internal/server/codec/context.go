@@ -0,0 +1,38 @@
+package codec
+
+import "context"
+
+type ctxKey int
+
+const (
+	requestKey ctxKey = iota
+	responseKey
+)
+
+// WithRequest returns context which carries codec of request body
+func WithRequest(ctx context.Context, c Codec) context.Context {
+	return context.WithValue(ctx, requestKey, c)
+}
+
+// Request returns codec of request body stored in context
+// JSON is returned if nothing was negotiated
+func Request(ctx context.Context) Codec {
+	if c, ok := ctx.Value(requestKey).(Codec); ok {
+		return c
+	}
+	return JSON
+}
+
+// WithResponse returns context which carries codec negotiated for response
+func WithResponse(ctx context.Context, c Codec) context.Context {
+	return context.WithValue(ctx, responseKey, c)
+}
+
+// Response returns codec negotiated for response stored in context
+// JSON is returned if nothing was negotiated
+func Response(ctx context.Context) Codec {
+	if c, ok := ctx.Value(responseKey).(Codec); ok {
+		return c
+	}
+	return JSON
+}
@@ -0,0 +1,43 @@
+package graphql
+
+import "github.com/bliuchak/heroes/internal/storage"
+
+const (
+	codeNotFound           = "NOT_FOUND"
+	codeConflict           = "CONFLICT"
+	codePreconditionFailed = "PRECONDITION_FAILED"
+	codeBadUserInput       = "BAD_USER_INPUT"
+	codeForbidden          = "FORBIDDEN"
+	codeUnavailable        = "UNAVAILABLE"
+	codeInternal           = "INTERNAL"
+)
+
+// codesOf maps storage error kinds to codes exposed in error extensions
+var codesOf = map[storage.Kind]string{
+	storage.ErrNotFound:           codeNotFound,
+	storage.ErrConflict:           codeConflict,
+	storage.ErrPreconditionFailed: codePreconditionFailed,
+	storage.ErrInvalid:            codeBadUserInput,
+	storage.ErrForbidden:          codeForbidden,
+	storage.ErrUnavailable:        codeUnavailable,
+	storage.ErrInternal:           codeInternal,
+}
+
+// resolverError is returned by resolvers, code is exposed in error extensions
+type resolverError struct {
+	code    string
+	message string
+}
+
+func newError(code, message string) *resolverError {
+	return &resolverError{code: code, message: message}
+}
+
+func (e *resolverError) Error() string {
+	return e.message
+}
+
+// Extensions implements extensions support of graphql-go errors
+func (e *resolverError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.code}
+}
@@ -0,0 +1,33 @@
+package graphql
+
+import (
+	_ "embed"
+	"net/http"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/storage"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/rs/zerolog"
+)
+
+// batchWait is how long loader waits for other lookups before calling storage
+const batchWait = time.Millisecond
+
+//go:embed schema.graphql
+var schema string
+
+// NewHandler returns http handler which executes GraphQL queries against storage
+func NewHandler(st storage.Storager, logger zerolog.Logger) http.Handler {
+	return newHandler(st, logger, batchWait)
+}
+
+func newHandler(st storage.Storager, logger zerolog.Logger, wait time.Duration) http.Handler {
+	s := graphqlgo.MustParseSchema(schema, &Resolver{Storage: st, Logger: logger})
+	h := &relay.Handler{Schema: s}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := withLoader(r.Context(), newHeroLoader(r.Context(), st, wait))
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,139 @@
+package graphql
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/auth"
+	"github.com/bliuchak/heroes/internal/storage"
+	stmocks "github.com/bliuchak/heroes/internal/storage/mocks"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type response struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func execute(t *testing.T, s storage.Storager, query string) response {
+	return executeAs(t, s, auth.Principal{Scopes: []string{auth.ScopeRead, auth.ScopeWrite}}, query)
+}
+
+func executeAs(t *testing.T, s storage.Storager, p auth.Principal, query string) response {
+	body, _ := json.Marshal(map[string]string{"query": query})
+
+	rr := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	r = r.WithContext(auth.NewContext(r.Context(), p))
+	// wait long enough so slow test runs still batch lookups
+	newHandler(s, zerolog.Nop(), 20*time.Millisecond).ServeHTTP(rr, r)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("handler returned unexpected response code: got %v want %v", rr.Code, http.StatusOK)
+	}
+
+	var resp response
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestHandler_HeroBatched(t *testing.T) {
+	s := new(stmocks.Storager)
+	s.On("GetHeroesByID", mock.Anything, mock.MatchedBy(func(ids []string) bool { return len(ids) == 2 })).
+		Return([]storage.Hero{{ID: "1", Name: "Batman"}}, nil).Once()
+
+	resp := execute(t, s, `{ a: hero(id: "1") { name } b: hero(id: "2") { name } c: hero(id: "1") { id } }`)
+
+	s.AssertExpectations(t)
+	assert.Equal(t, map[string]interface{}{"name": "Batman"}, resp.Data["a"])
+	assert.Nil(t, resp.Data["b"])
+	assert.Len(t, resp.Errors, 1)
+	assert.Equal(t, "NOT_FOUND", resp.Errors[0].Extensions["code"])
+}
+
+func TestHandler_HeroStorageError(t *testing.T) {
+	s := new(stmocks.Storager)
+	s.On("GetHeroesByID", mock.Anything, []string{"1"}).Return(nil, errors.New("MGET error")).Once()
+
+	resp := execute(t, s, `{ hero(id: "1") { name } }`)
+
+	assert.Len(t, resp.Errors, 1)
+	assert.Equal(t, "INTERNAL", resp.Errors[0].Extensions["code"])
+	assert.Equal(t, "internal error", resp.Errors[0].Message)
+}
+
+func TestHandler_Heroes(t *testing.T) {
+	s := new(stmocks.Storager)
+	s.On("GetHeroes", mock.Anything).Return([]storage.Hero{
+		{ID: "10", Name: "Flash"},
+		{ID: "2", Name: "Superman"},
+		{ID: "1", Name: "Batman"},
+	}, nil)
+
+	resp := execute(t, s, `{ heroes(first: 2, after: "1") { nodes { id } totalCount pageInfo { hasNextPage endCursor } } }`)
+
+	assert.Empty(t, resp.Errors)
+	assert.Equal(t, map[string]interface{}{
+		"nodes":      []interface{}{map[string]interface{}{"id": "2"}, map[string]interface{}{"id": "10"}},
+		"totalCount": float64(3),
+		"pageInfo":   map[string]interface{}{"hasNextPage": false, "endCursor": "10"},
+	}, resp.Data["heroes"])
+}
+
+func TestHandler_Mutations(t *testing.T) {
+	s := new(stmocks.Storager)
+	s.On("CreateHero", mock.Anything, "1", "Batman", storage.Owner{}).Return(nil)
+	s.On("DeleteHero", mock.Anything, "1", storage.Owner{}).Return(storage.NewError(storage.ErrNotFound, "DeleteHero", "1", nil))
+
+	resp := execute(t, s, `mutation { createHero(id: "1", name: "Batman") { id name } }`)
+	assert.Empty(t, resp.Errors)
+	assert.Equal(t, map[string]interface{}{"id": "1", "name": "Batman"}, resp.Data["createHero"])
+
+	resp = execute(t, s, `mutation { createHero(id: "abc", name: "Batman") { id } }`)
+	assert.Equal(t, "BAD_USER_INPUT", resp.Errors[0].Extensions["code"])
+
+	resp = execute(t, s, `mutation { deleteHero(id: "1") }`)
+	assert.Equal(t, "NOT_FOUND", resp.Errors[0].Extensions["code"])
+}
+
+func TestHandler_Introspection(t *testing.T) {
+	resp := execute(t, new(stmocks.Storager), `{ __type(name: "Hero") { fields { name } } }`)
+
+	assert.Empty(t, resp.Errors)
+	assert.Equal(t, map[string]interface{}{
+		"fields": []interface{}{map[string]interface{}{"name": "id"}, map[string]interface{}{"name": "name"}},
+	}, resp.Data["__type"])
+}
+
+func TestHandler_MutationRequiresWriteScope(t *testing.T) {
+	s := new(stmocks.Storager)
+
+	resp := executeAs(t, s, auth.Principal{Scopes: []string{auth.ScopeRead}}, `mutation { deleteHero(id: "1") }`)
+
+	s.AssertExpectations(t)
+	assert.Len(t, resp.Errors, 1)
+	assert.Equal(t, "FORBIDDEN", resp.Errors[0].Extensions["code"])
+}
+
+func TestHandler_MutationOfOtherOwnersHero(t *testing.T) {
+	s := new(stmocks.Storager)
+	s.On("DeleteHero", mock.Anything, "1", storage.Owner{ID: "apikey:2"}).Return(storage.NewError(storage.ErrForbidden, "DeleteHero", "1", nil))
+
+	p := auth.Principal{Subject: "apikey:2", Scopes: []string{auth.ScopeWrite}}
+	resp := executeAs(t, s, p, `mutation { deleteHero(id: "1") }`)
+
+	assert.Len(t, resp.Errors, 1)
+	assert.Equal(t, "FORBIDDEN", resp.Errors[0].Extensions["code"])
+}
@@ -0,0 +1,118 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/storage"
+)
+
+type loaderKey struct{}
+
+type heroResult struct {
+	hero storage.Hero
+	err  error
+}
+
+// heroLoader collects hero lookups made by resolvers of single query and
+// fetches them from storage in one batch, results are kept until the end
+// of the query so every hero is loaded at most once
+type heroLoader struct {
+	// ctx of request, batches are fetched on behalf of whole query
+	ctx     context.Context
+	storage storage.Storager
+	wait    time.Duration
+
+	mu      sync.Mutex
+	pending map[string][]chan heroResult
+	results map[string]heroResult
+}
+
+func newHeroLoader(ctx context.Context, st storage.Storager, wait time.Duration) *heroLoader {
+	return &heroLoader{
+		ctx:     ctx,
+		storage: st,
+		wait:    wait,
+		pending: make(map[string][]chan heroResult),
+		results: make(map[string]heroResult),
+	}
+}
+
+func withLoader(ctx context.Context, l *heroLoader) context.Context {
+	return context.WithValue(ctx, loaderKey{}, l)
+}
+
+func loaderFrom(ctx context.Context) *heroLoader {
+	return ctx.Value(loaderKey{}).(*heroLoader)
+}
+
+// Load returns hero by ID, lookups made within wait period share single
+// storage call
+func (l *heroLoader) Load(id string) (storage.Hero, error) {
+	l.mu.Lock()
+	if res, ok := l.results[id]; ok {
+		l.mu.Unlock()
+		return res.hero, res.err
+	}
+
+	ch := make(chan heroResult, 1)
+	if len(l.pending) == 0 {
+		time.AfterFunc(l.wait, l.dispatch)
+	}
+	l.pending[id] = append(l.pending[id], ch)
+	l.mu.Unlock()
+
+	res := <-ch
+	return res.hero, res.err
+}
+
+// Prime stores heroes loaded by other means, e.g. by list query
+func (l *heroLoader) Prime(hs []storage.Hero) {
+	l.mu.Lock()
+	for _, h := range hs {
+		l.results[h.ID] = heroResult{hero: h}
+	}
+	l.mu.Unlock()
+}
+
+func (l *heroLoader) dispatch() {
+	l.mu.Lock()
+	pending := l.pending
+	l.pending = make(map[string][]chan heroResult)
+	l.mu.Unlock()
+
+	ids := make([]string, 0, len(pending))
+	for id := range pending {
+		ids = append(ids, id)
+	}
+
+	results := make(map[string]heroResult, len(ids))
+	hs, err := l.storage.GetHeroesByID(l.ctx, ids)
+	for _, id := range ids {
+		results[id] = heroResult{err: err}
+		if err == nil {
+			results[id] = heroResult{err: storage.NewError(storage.ErrNotFound, "GetHero", id, nil)}
+		}
+	}
+	if err == nil {
+		for _, h := range hs {
+			results[h.ID] = heroResult{hero: h}
+		}
+	}
+
+	l.mu.Lock()
+	for id, res := range results {
+		// storage errors are not kept so later lookups can retry
+		if err == nil {
+			l.results[id] = res
+		}
+	}
+	l.mu.Unlock()
+
+	for id, chs := range pending {
+		for _, ch := range chs {
+			ch <- results[id]
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package graphql
+
+import (
+	"context"
+	"regexp"
+	"sort"
+
+	"github.com/bliuchak/heroes/internal/apierror"
+	"github.com/bliuchak/heroes/internal/auth"
+	"github.com/bliuchak/heroes/internal/server/requestid"
+	"github.com/bliuchak/heroes/internal/storage"
+	graphqlgo "github.com/graph-gophers/graphql-go"
+	"github.com/rs/zerolog"
+)
+
+const maxPageSize = 100
+
+// validID has the same format as {id} in http routes
+var validID = regexp.MustCompile(`^[0-9]+$`)
+
+// Resolver is root resolver for queries and mutations
+type Resolver struct {
+	Storage storage.Storager
+	Logger  zerolog.Logger
+}
+
+// Hero resolves single hero by ID
+func (r *Resolver) Hero(ctx context.Context, args struct{ ID graphqlgo.ID }) (*heroResolver, error) {
+	id := string(args.ID)
+	if !validID.MatchString(id) {
+		return nil, newError(codeBadUserInput, "invalid hero id")
+	}
+
+	h, err := loaderFrom(ctx).Load(id)
+	if err != nil {
+		return nil, r.toError(ctx, err, "Unable to get hero")
+	}
+
+	return &heroResolver{h}, nil
+}
+
+// Heroes resolves page of heroes ordered by ID
+func (r *Resolver) Heroes(ctx context.Context, args struct {
+	First int32
+	After *graphqlgo.ID
+}) (*heroConnectionResolver, error) {
+	first := int(args.First)
+	if first < 0 || first > maxPageSize {
+		return nil, newError(codeBadUserInput, "first must be between 0 and 100")
+	}
+
+	hs, err := r.Storage.GetHeroes(ctx)
+	if err != nil {
+		return nil, r.toError(ctx, err, "Unable to get heroes")
+	}
+	loaderFrom(ctx).Prime(hs)
+
+	sort.Slice(hs, func(i, j int) bool { return lessID(hs[i].ID, hs[j].ID) })
+
+	start := 0
+	if args.After != nil {
+		after := string(*args.After)
+		start = sort.Search(len(hs), func(i int) bool { return lessID(after, hs[i].ID) })
+	}
+
+	end := start + first
+	if end > len(hs) {
+		end = len(hs)
+	}
+
+	return &heroConnectionResolver{
+		heroes:      hs[start:end],
+		total:       len(hs),
+		hasNextPage: end < len(hs),
+	}, nil
+}
+
+// CreateHero creates new hero
+func (r *Resolver) CreateHero(ctx context.Context, args struct {
+	ID   graphqlgo.ID
+	Name string
+}) (*heroResolver, error) {
+	if !auth.Allowed(ctx, auth.ScopeWrite) {
+		return nil, newError(codeForbidden, "scope "+auth.ScopeWrite+" is required")
+	}
+
+	h := storage.Hero{ID: string(args.ID), Name: args.Name}
+	if !h.IsValid() || !validID.MatchString(h.ID) {
+		return nil, newError(codeBadUserInput, "hero is not valid")
+	}
+
+	if err := r.Storage.CreateHero(ctx, h.ID, h.Name, auth.OwnerOf(ctx)); err != nil {
+		return nil, r.toError(ctx, err, "Unable to send create hero request")
+	}
+
+	return &heroResolver{h}, nil
+}
+
+// DeleteHero deletes hero by ID
+func (r *Resolver) DeleteHero(ctx context.Context, args struct{ ID graphqlgo.ID }) (bool, error) {
+	if !auth.Allowed(ctx, auth.ScopeWrite) {
+		return false, newError(codeForbidden, "scope "+auth.ScopeWrite+" is required")
+	}
+
+	id := string(args.ID)
+	if !validID.MatchString(id) {
+		return false, newError(codeBadUserInput, "invalid hero id")
+	}
+
+	if err := r.Storage.DeleteHero(ctx, id, auth.OwnerOf(ctx)); err != nil {
+		return false, r.toError(ctx, err, "Unable to delete hero")
+	}
+
+	return true, nil
+}
+
+// toError maps storage errors the same way http handlers map them
+// to response codes, unexpected errors are logged
+func (r *Resolver) toError(ctx context.Context, err error, msg string) error {
+	e := apierror.Log(requestid.Logger(ctx, r.Logger), err, msg)
+	return newError(codesOf[e.Kind], e.Message)
+}
+
+// lessID compares numeric IDs without parsing them
+func lessID(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+type heroResolver struct {
+	h storage.Hero
+}
+
+func (r *heroResolver) ID() graphqlgo.ID {
+	return graphqlgo.ID(r.h.ID)
+}
+
+func (r *heroResolver) Name() string {
+	return r.h.Name
+}
+
+type heroConnectionResolver struct {
+	heroes      []storage.Hero
+	total       int
+	hasNextPage bool
+}
+
+func (r *heroConnectionResolver) Nodes() []*heroResolver {
+	res := make([]*heroResolver, len(r.heroes))
+	for i, h := range r.heroes {
+		res[i] = &heroResolver{h}
+	}
+	return res
+}
+
+func (r *heroConnectionResolver) TotalCount() int32 {
+	return int32(r.total)
+}
+
+func (r *heroConnectionResolver) PageInfo() *pageInfoResolver {
+	p := &pageInfoResolver{hasNextPage: r.hasNextPage}
+	if len(r.heroes) > 0 {
+		id := graphqlgo.ID(r.heroes[len(r.heroes)-1].ID)
+		p.endCursor = &id
+	}
+	return p
+}
+
+type pageInfoResolver struct {
+	hasNextPage bool
+	endCursor   *graphqlgo.ID
+}
+
+func (r *pageInfoResolver) HasNextPage() bool {
+	return r.hasNextPage
+}
+
+func (r *pageInfoResolver) EndCursor() *graphqlgo.ID {
+	return r.endCursor
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/bliuchak/heroes/internal/auth"
+	"github.com/bliuchak/heroes/internal/server/problem"
+	"github.com/bliuchak/heroes/internal/storage"
+	"github.com/gorilla/mux"
+)
+
+// APIKeyHandler contains handlers to manage API keys
+// extend common handler
+type APIKeyHandler struct {
+	CommonHandler
+	Keys storage.KeyStorager
+}
+
+// CreateAPIKeyRequest describes API key to create
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+// CreateAPIKeyResponse contains created API key, Key is shown only once
+type CreateAPIKeyResponse struct {
+	storage.APIKey
+	Key string `json:"key"`
+}
+
+// CreateAPIKeyHandler handler to create API key
+func (kh *APIKeyHandler) CreateAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		kh.writeError(w, r, err, "Unable to read body")
+		return
+	}
+
+	var req CreateAPIKeyRequest
+	err = kh.Unmarshal(b, &req)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(err.Error()))
+		return
+	}
+
+	if req.Name == "" || len(req.Scopes) == 0 {
+		problem.Write(w, r, problem.Invalid("name and scopes are required"))
+		return
+	}
+	for _, s := range req.Scopes {
+		if !auth.ValidScope(s) {
+			problem.Write(w, r, problem.Invalid("unknown scope "+s))
+			return
+		}
+	}
+
+	key, token, err := auth.NewAPIKey(req.Name, req.Scopes)
+	if err != nil {
+		kh.writeError(w, r, err, "Unable to generate API key")
+		return
+	}
+
+	err = kh.Keys.CreateAPIKey(r.Context(), key)
+	if err != nil {
+		kh.writeError(w, r, err, "Unable to store API key")
+		return
+	}
+
+	kh.respond(w, r, http.StatusCreated, CreateAPIKeyResponse{APIKey: key, Key: token})
+}
+
+// GetAPIKeysHandler handler to list API keys, secrets are never listed
+func (kh *APIKeyHandler) GetAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := kh.Keys.GetAPIKeys(r.Context())
+	if err != nil {
+		kh.writeError(w, r, err, "Unable to get API keys")
+		return
+	}
+
+	if keys == nil {
+		keys = []storage.APIKey{}
+	}
+	kh.respond(w, r, http.StatusOK, keys)
+}
+
+// DeleteAPIKeyHandler handler to revoke API key
+func (kh *APIKeyHandler) DeleteAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	v := mux.Vars(r)
+
+	err := kh.Keys.DeleteAPIKey(r.Context(), v["id"])
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			problem.Write(w, r, problem.New(http.StatusNotFound, "API key "+v["id"]+" doesn't exist"))
+			return
+		}
+		kh.writeError(w, r, err, "Unable to revoke API key")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bliuchak/heroes/internal/auth"
+	"github.com/bliuchak/heroes/internal/storage"
+	stmocks "github.com/bliuchak/heroes/internal/storage/mocks"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAPIKeyHandler_CreateAPIKeyHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		storeErr error
+		expected expected
+	}{
+		{
+			name:     "should create key",
+			body:     `{"name":"ci","scopes":["heroes:read"]}`,
+			expected: expected{code: http.StatusCreated},
+		},
+		{
+			name:     "should refuse key without scopes",
+			body:     `{"name":"ci","scopes":[]}`,
+			expected: expected{code: http.StatusBadRequest},
+		},
+		{
+			name:     "should refuse unknown scope",
+			body:     `{"name":"ci","scopes":["heroes:fly"]}`,
+			expected: expected{code: http.StatusBadRequest},
+		},
+		{
+			name:     "should return error from storage",
+			body:     `{"name":"ci","scopes":["admin"]}`,
+			storeErr: errors.New("HSET error"),
+			expected: expected{code: http.StatusInternalServerError},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys := new(stmocks.KeyStorager)
+			var stored storage.APIKey
+			keys.On("CreateAPIKey", mock.Anything, mock.Anything).Return(tt.storeErr).
+				Run(func(args mock.Arguments) { stored = args.Get(1).(storage.APIKey) })
+
+			kh := APIKeyHandler{Keys: keys}
+			rr := httptest.NewRecorder()
+			kh.CreateAPIKeyHandler(rr, httptest.NewRequest(http.MethodPost, "/v1/admin/keys", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.expected.code, rr.Code)
+			if rr.Code != http.StatusCreated {
+				return
+			}
+
+			var resp CreateAPIKeyResponse
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, stored.ID, resp.ID)
+			assert.Equal(t, []string{auth.ScopeRead}, resp.Scopes)
+
+			// only hash of secret is stored
+			id, secret, ok := auth.ParseToken(resp.Key)
+			assert.True(t, ok)
+			assert.Equal(t, stored.ID, id)
+			assert.NotContains(t, stored.Hash, secret)
+			assert.True(t, auth.VerifySecret(stored, secret))
+		})
+	}
+}
+
+func TestAPIKeyHandler_DeleteAPIKeyHandler(t *testing.T) {
+	keys := new(stmocks.KeyStorager)
+	keys.On("DeleteAPIKey", mock.Anything, "a1").Return(nil)
+	keys.On("DeleteAPIKey", mock.Anything, "b2").Return(storage.NewError(storage.ErrNotFound, "DeleteAPIKey", "b2", nil))
+
+	kh := APIKeyHandler{Keys: keys}
+
+	for id, code := range map[string]int{"a1": http.StatusNoContent, "b2": http.StatusNotFound} {
+		rr := httptest.NewRecorder()
+		r := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/v1/admin/keys/"+id, nil), map[string]string{"id": id})
+		kh.DeleteAPIKeyHandler(rr, r)
+		assert.Equal(t, code, rr.Code)
+	}
+}
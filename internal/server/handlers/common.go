@@ -2,7 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"net/http"
 
+	"github.com/bliuchak/heroes/internal/apierror"
+	"github.com/bliuchak/heroes/internal/server/codec"
+	"github.com/bliuchak/heroes/internal/server/problem"
+	"github.com/bliuchak/heroes/internal/server/requestid"
 	"github.com/bliuchak/heroes/internal/storage"
 	"github.com/rs/zerolog"
 )
@@ -20,6 +26,12 @@ func (ch *CommonHandler) SetLogger(logger zerolog.Logger) {
 	ch.Logger = logger
 }
 
+// logger returns logger of request r, it adds request ID to every entry,
+// Logger is used for requests without ID
+func (ch *CommonHandler) logger(r *http.Request) *zerolog.Logger {
+	return requestid.Logger(r.Context(), ch.Logger)
+}
+
 // SetStorage sets storage
 func (ch *CommonHandler) SetStorage(st storage.Storager) {
 	ch.Storage = st
@@ -42,3 +54,52 @@ func (ch *CommonHandler) Unmarshal(data []byte, v interface{}) error {
 	}
 	return ch.Unmarshaler(data, &v)
 }
+
+// MarshalResponse marshals the provided value with codec negotiated for r
+// and returns media type of the data. JSON goes through Marshal.
+func (ch *CommonHandler) MarshalResponse(r *http.Request, v interface{}) ([]byte, string, error) {
+	c := codec.Response(r.Context())
+	if c.MediaType == codec.JSON.MediaType {
+		data, err := ch.Marshal(v)
+		return data, c.MediaType, err
+	}
+	data, err := c.Marshal(v)
+	return data, c.MediaType, err
+}
+
+// UnmarshalRequest unmarshals body of r with codec selected by its Content-Type.
+// JSON goes through Unmarshal.
+func (ch *CommonHandler) UnmarshalRequest(r *http.Request, data []byte, v interface{}) error {
+	c := codec.Request(r.Context())
+	if c.MediaType == codec.JSON.MediaType {
+		return ch.Unmarshal(data, v)
+	}
+	return c.Unmarshal(data, v)
+}
+
+// respond writes the provided value in negotiated media type with given status code
+func (ch *CommonHandler) respond(w http.ResponseWriter, r *http.Request, code int, v interface{}) {
+	data, mediaType, err := ch.MarshalResponse(r, v)
+	if err != nil {
+		if errors.Is(err, codec.ErrUnsupportedValue) {
+			problem.Write(w, r, problem.New(http.StatusNotAcceptable, err.Error()))
+			return
+		}
+		ch.writeError(w, r, err, "Unable to marshall data")
+		return
+	}
+
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(code)
+	w.Write(data)
+}
+
+// writeError writes err as problem details, errors which are not caused by
+// client (5xx) are logged with msg
+func (ch *CommonHandler) writeError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	var p *problem.Problem
+	if !errors.As(err, &p) {
+		p = problem.FromAPIError(apierror.Log(ch.logger(r), err, msg))
+	}
+	problem.Write(w, r, p)
+}
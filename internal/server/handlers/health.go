@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/bliuchak/heroes/internal/health"
+)
+
+// HealthHandler contains liveness and readiness probes
+// extend common handler
+type HealthHandler struct {
+	CommonHandler
+	// Readiness reports whether server accepts new requests and the reason
+	// when it doesn't, if un-set server is considered ready
+	Readiness func() (bool, string)
+	Monitor   *health.Monitor
+}
+
+// LivenessResponse displays that process is alive
+type LivenessResponse struct {
+	Status string `json:"status"`
+}
+
+// ReadinessResponse displays whether app is ready to serve requests
+// together with state of every dependency
+type ReadinessResponse struct {
+	Status       string                   `json:"status"`
+	Reason       string                   `json:"reason,omitempty"`
+	Dependencies map[string]health.Result `json:"dependencies"`
+}
+
+// readiness states
+const (
+	statusReady    = "ready"
+	statusNotReady = "not ready"
+)
+
+// GetLivenessHandler handle for liveness probe, it doesn't check
+// dependencies, so restarting app because of storage outage is avoided
+func (hh *HealthHandler) GetLivenessHandler(w http.ResponseWriter, r *http.Request) {
+	hh.respond(w, r, http.StatusOK, LivenessResponse{Status: "ok"})
+}
+
+// GetReadinessHandler handle for readiness probe
+func (hh *HealthHandler) GetReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	resp := ReadinessResponse{
+		Status:       statusReady,
+		Dependencies: map[string]health.Result{},
+	}
+
+	ready, reason := true, ""
+	if hh.Readiness != nil {
+		ready, reason = hh.Readiness()
+	}
+
+	up := true
+	if hh.Monitor != nil {
+		resp.Dependencies, up = hh.Monitor.Check(r.Context())
+	}
+	if ready && !up {
+		ready, reason = false, "dependency is down"
+	}
+
+	code := http.StatusOK
+	if !ready {
+		code = http.StatusServiceUnavailable
+		resp.Status = statusNotReady
+		resp.Reason = reason
+	}
+	hh.respond(w, r, code, resp)
+}
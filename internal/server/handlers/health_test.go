@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/health"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthHandler_GetLivenessHandler(t *testing.T) {
+	hh := HealthHandler{
+		// liveness doesn't depend on readiness nor dependencies
+		Readiness: func() (bool, string) { return false, "server is shutting down" },
+		Monitor: health.NewMonitor(time.Second, health.Dependency{
+			Name:  "storage",
+			Check: func(context.Context) error { return errors.New("connection refused") },
+		}),
+	}
+	rr := httptest.NewRecorder()
+
+	hh.GetLivenessHandler(rr, httptest.NewRequest("GET", "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rr.Body.String())
+}
+
+func TestHealthHandler_GetReadinessHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		readiness  func() (bool, string)
+		storageErr error
+		expected   expected
+		status     string
+		reason     string
+	}{
+		{
+			name:     "should return ready when all dependencies are up",
+			expected: expected{code: http.StatusOK},
+			status:   "ready",
+		},
+		{
+			name:       "should return not ready when dependency is down",
+			storageErr: errors.New("connection refused"),
+			expected:   expected{code: http.StatusServiceUnavailable},
+			status:     "not ready",
+			reason:     "dependency is down",
+		},
+		{
+			name:      "should return not ready in maintenance mode",
+			readiness: func() (bool, string) { return false, "server is in maintenance mode" },
+			expected:  expected{code: http.StatusServiceUnavailable},
+			status:    "not ready",
+			reason:    "server is in maintenance mode",
+		},
+		{
+			name:      "should return not ready on shutdown",
+			readiness: func() (bool, string) { return false, "server is shutting down" },
+			expected:  expected{code: http.StatusServiceUnavailable},
+			status:    "not ready",
+			reason:    "server is shutting down",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hh := HealthHandler{
+				Readiness: tt.readiness,
+				Monitor: health.NewMonitor(time.Second, health.Dependency{
+					Name:  "storage",
+					Check: func(context.Context) error { return tt.storageErr },
+					Stats: func() map[string]uint64 { return map[string]uint64{"pool_in_use": 1} },
+				}),
+			}
+			rr := httptest.NewRecorder()
+
+			hh.GetReadinessHandler(rr, httptest.NewRequest("GET", "/readyz", nil))
+
+			assert.Equal(t, tt.expected.code, rr.Code)
+
+			var resp ReadinessResponse
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &resp))
+			assert.Equal(t, tt.status, resp.Status)
+			assert.Equal(t, tt.reason, resp.Reason)
+			assert.Equal(t, uint64(1), resp.Dependencies["storage"].Stats["pool_in_use"])
+			if tt.storageErr != nil {
+				assert.Equal(t, health.StatusDown, resp.Dependencies["storage"].Status)
+				assert.Equal(t, tt.storageErr.Error(), resp.Dependencies["storage"].LastError)
+			}
+		})
+	}
+}
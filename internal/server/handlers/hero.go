@@ -1,9 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 
+	"github.com/bliuchak/heroes/internal/auth"
+	"github.com/bliuchak/heroes/internal/server/problem"
 	"github.com/bliuchak/heroes/internal/storage"
 	"github.com/gorilla/mux"
 )
@@ -12,28 +16,39 @@ import (
 // extend common handler
 type HeroHandler struct {
 	CommonHandler
+	// Represent converts hero to its response representation
+	// API versions may use it to expose different hero format
+	// if un-set, storage.Hero is used as is
+	Represent func(h storage.Hero) interface{}
+}
+
+// represent converts hero to response representation, owner of hero is
+// exposed only to owner itself and admin
+func (hh *HeroHandler) represent(r *http.Request, h storage.Hero) interface{} {
+	if o := auth.OwnerOf(r.Context()); !o.Admin && o.ID != h.Owner {
+		h.Owner = ""
+	}
+	if hh.Represent == nil {
+		return h
+	}
+	return hh.Represent(h)
 }
 
 // GetHeroesHandler handler to get all heroes
 func (hh *HeroHandler) GetHeroesHandler(w http.ResponseWriter, r *http.Request) {
-	hs, err := hh.Storage.GetHeroes()
+	hs, err := hh.Storage.GetHeroes(r.Context())
 	if err != nil {
-		hh.Logger.Error().Err(err).Msg("Unable to get heroes")
-		w.WriteHeader(http.StatusInternalServerError)
+		hh.writeError(w, r, err, "Unable to get heroes")
 		return
 	}
 
 	if len(hs) > 0 {
-		data, err := hh.Marshal(hs)
-		if err != nil {
-			hh.Logger.Error().Err(err).Msg("Unable to marshall data")
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+		resp := make([]interface{}, len(hs))
+		for i, h := range hs {
+			resp[i] = hh.represent(r, h)
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		w.Write(data)
+		hh.respond(w, r, http.StatusOK, resp)
 		return
 	}
 
@@ -41,32 +56,37 @@ func (hh *HeroHandler) GetHeroesHandler(w http.ResponseWriter, r *http.Request)
 	return
 }
 
-// GetHeroHandler handler to get single hero
-func (hh *HeroHandler) GetHeroHandler(w http.ResponseWriter, r *http.Request) {
-	v := mux.Vars(r)
-	h, err := hh.Storage.GetHero(v["id"])
+// GetMyHeroesHandler handler to get heroes created by authenticated client
+func (hh *HeroHandler) GetMyHeroesHandler(w http.ResponseWriter, r *http.Request) {
+	hs, err := hh.Storage.GetHeroesByOwner(r.Context(), auth.OwnerOf(r.Context()).ID)
 	if err != nil {
-		switch err.(type) {
-		case *storage.ErrHeroNotExist:
-			w.WriteHeader(http.StatusNotFound)
-			return
-		default:
-			hh.Logger.Error().Err(err).Msg("Unable to ger var from url")
-			w.WriteHeader(http.StatusInternalServerError)
-			return
+		hh.writeError(w, r, err, "Unable to get heroes")
+		return
+	}
+
+	if len(hs) > 0 {
+		resp := make([]interface{}, len(hs))
+		for i, h := range hs {
+			resp[i] = hh.represent(r, h)
 		}
+
+		hh.respond(w, r, http.StatusOK, resp)
+		return
 	}
 
-	data, err := hh.Marshal(h)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetHeroHandler handler to get single hero
+func (hh *HeroHandler) GetHeroHandler(w http.ResponseWriter, r *http.Request) {
+	v := mux.Vars(r)
+	h, err := hh.Storage.GetHero(r.Context(), v["id"])
 	if err != nil {
-		hh.Logger.Error().Err(err).Msg("Unable to marshall data")
-		w.WriteHeader(http.StatusInternalServerError)
+		hh.writeError(w, r, err, "Unable to get hero")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write(data)
+	hh.respond(w, r, http.StatusOK, hh.represent(r, h))
 }
 
 // CreateHeroHandler handler to create a new hero
@@ -74,43 +94,81 @@ func (hh *HeroHandler) CreateHeroHandler(w http.ResponseWriter, r *http.Request)
 	defer r.Body.Close()
 	b, err := ioutil.ReadAll(r.Body)
 	if err != nil {
-		hh.Logger.Error().Err(err).Msg("Unable to read body")
-		w.WriteHeader(http.StatusInternalServerError)
+		hh.writeError(w, r, err, "Unable to read body")
 		return
 	}
 
 	var hero storage.Hero
-	err = hh.Unmarshal(b, &hero)
+	err = hh.UnmarshalRequest(r, b, &hero)
 	if err != nil {
-		hh.Logger.Error().Err(err).Msg("Unable to unmarshall data")
-		w.WriteHeader(http.StatusInternalServerError)
+		problem.Write(w, r, problem.Invalid(err.Error()))
 		return
 	}
 
-	err = hh.Storage.CreateHero(hero.ID, hero.Name)
+	err = hh.Storage.CreateHero(r.Context(), hero.ID, hero.Name, auth.OwnerOf(r.Context()))
 	if err != nil {
-		hh.Logger.Error().Err(err).Msg("Unable to send create hero request")
-		w.WriteHeader(http.StatusInternalServerError)
+		hh.writeError(w, r, err, "Unable to send create hero request")
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
+// UpdateHeroHandler handler to update name of existing hero
+func (hh *HeroHandler) UpdateHeroHandler(w http.ResponseWriter, r *http.Request) {
+	v := mux.Vars(r)
+
+	defer r.Body.Close()
+	b, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		hh.writeError(w, r, err, "Unable to read body")
+		return
+	}
+
+	var hero storage.Hero
+	err = hh.UnmarshalRequest(r, b, &hero)
+	if err != nil {
+		problem.Write(w, r, problem.Invalid(err.Error()))
+		return
+	}
+
+	hero.ID = v["id"]
+	if !hero.IsValid() {
+		problem.Write(w, r, problem.Invalid("hero id and name are required"))
+		return
+	}
+
+	err = hh.Storage.UpdateHero(r.Context(), hero.ID, hero.Name, auth.OwnerOf(r.Context()))
+	if err != nil {
+		hh.writeError(w, r, err, "Unable to send update hero request")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 // DeleteHeroHandler handler to delete hero
+// responds 404 when hero doesn't exist unless ?must_exist=false is set
 func (hh *HeroHandler) DeleteHeroHandler(w http.ResponseWriter, r *http.Request) {
+	mustExist := true
+	if q := r.URL.Query().Get("must_exist"); q != "" {
+		var err error
+		mustExist, err = strconv.ParseBool(q)
+		if err != nil {
+			problem.Write(w, r, problem.Invalid("must_exist must be a boolean"))
+			return
+		}
+	}
+
 	v := mux.Vars(r)
-	err := hh.Storage.DeleteHero(v["id"])
+	err := hh.Storage.DeleteHero(r.Context(), v["id"], auth.OwnerOf(r.Context()))
 	if err != nil {
-		switch err.(type) {
-		case *storage.ErrNothingToDelete:
-			w.WriteHeader(http.StatusNotFound)
-			return
-		default:
-			hh.Logger.Error().Err(err).Msg("Unable to ger var from url")
-			w.WriteHeader(http.StatusInternalServerError)
+		if !mustExist && errors.Is(err, storage.ErrNotFound) {
+			w.WriteHeader(http.StatusNoContent)
 			return
 		}
+		hh.writeError(w, r, err, "Unable to send delete hero request")
+		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
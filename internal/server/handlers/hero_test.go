@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"io"
 	"net/http"
@@ -8,8 +10,15 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/bliuchak/heroes/internal/auth"
+	"github.com/bliuchak/heroes/internal/server/codec"
+	"github.com/bliuchak/heroes/internal/server/problem"
+	"github.com/bliuchak/heroes/internal/server/requestid"
 	"github.com/bliuchak/heroes/internal/storage"
 	stmocks "github.com/bliuchak/heroes/internal/storage/mocks"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
 	. "github.com/stretchr/testify/mock"
 )
 
@@ -21,6 +30,11 @@ type TestifyMockCall struct {
 	Times    int
 }
 
+// args returns expected arguments of call, any context is accepted
+func (c TestifyMockCall) args() []interface{} {
+	return append([]interface{}{Anything}, c.Call...)
+}
+
 type expected struct {
 	code int
 }
@@ -119,7 +133,7 @@ func TestHeroHandler_GetHeroesHandler(t *testing.T) {
 
 			s := new(stmocks.Storager)
 			for _, mockCall := range tt.storage {
-				s.On(mockCall.Method, mockCall.Call...).Return(mockCall.Response...)
+				s.On(mockCall.Method, mockCall.args()...).Return(mockCall.Response...)
 			}
 
 			hh.SetStorage(s)
@@ -160,7 +174,7 @@ func TestHeroHandler_CreateHeroHandler(t *testing.T) {
 				return errors.New("unmarshal error")
 			},
 			expected: expected{
-				code: http.StatusInternalServerError,
+				code: http.StatusBadRequest,
 			},
 		},
 		{
@@ -172,6 +186,7 @@ func TestHeroHandler_CreateHeroHandler(t *testing.T) {
 					Call: []interface{}{
 						AnythingOfType("string"),
 						AnythingOfType("string"),
+						AnythingOfType("storage.Owner"),
 					},
 					Response: []interface{}{
 						errors.New("create hero error"),
@@ -191,6 +206,7 @@ func TestHeroHandler_CreateHeroHandler(t *testing.T) {
 					Call: []interface{}{
 						AnythingOfType("string"),
 						AnythingOfType("string"),
+						AnythingOfType("storage.Owner"),
 					},
 					Response: []interface{}{
 						nil,
@@ -214,18 +230,17 @@ func TestHeroHandler_CreateHeroHandler(t *testing.T) {
 
 			s := new(stmocks.Storager)
 			for _, mockCall := range tt.storage {
-				s.On(mockCall.Method, mockCall.Call...).Return(mockCall.Response...)
+				s.On(mockCall.Method, mockCall.args()...).Return(mockCall.Response...)
 			}
 
 			hh.SetStorage(s)
-
-			r := httptest.NewRequest("POST", "/hero", reader)
-			hh.CreateHeroHandler(rr, r)
-
 			if tt.unmarshaler != nil {
 				hh.Unmarshaler = tt.unmarshaler
 			}
 
+			r := httptest.NewRequest("POST", "/hero", reader)
+			hh.CreateHeroHandler(rr, r)
+
 			if rr.Code != tt.expected.code {
 				t.Errorf("handler returned unexpected response code: got %v want %v",
 					rr.Code, tt.expected.code)
@@ -242,7 +257,7 @@ func TestHeroHandler_GetHeroHandler(t *testing.T) {
 		expected  expected
 	}{
 		{
-			name: "should return storage.ErrHeroNotExist on hh.Storage.GetHero",
+			name: "should return storage.ErrNotFound on hh.Storage.GetHero",
 			storage: []TestifyMockCall{
 				{
 					Method: "GetHero",
@@ -251,7 +266,7 @@ func TestHeroHandler_GetHeroHandler(t *testing.T) {
 					},
 					Response: []interface{}{
 						storage.Hero{},
-						storage.NewErrHeroNotExist("dummy"),
+						storage.NewError(storage.ErrNotFound, "GetHero", "1", nil),
 					},
 				},
 			},
@@ -328,7 +343,7 @@ func TestHeroHandler_GetHeroHandler(t *testing.T) {
 
 			s := new(stmocks.Storager)
 			for _, mockCall := range tt.storage {
-				s.On(mockCall.Method, mockCall.Call...).Return(mockCall.Response...)
+				s.On(mockCall.Method, mockCall.args()...).Return(mockCall.Response...)
 			}
 
 			hh := HeroHandler{}
@@ -354,6 +369,7 @@ func TestHeroHandler_GetHeroHandler(t *testing.T) {
 func TestHeroHandler_DeleteHeroHandler(t *testing.T) {
 	tests := []struct {
 		name     string
+		query    string
 		storage  []TestifyMockCall
 		expected expected
 	}{
@@ -364,9 +380,10 @@ func TestHeroHandler_DeleteHeroHandler(t *testing.T) {
 					Method: "DeleteHero",
 					Call: []interface{}{
 						AnythingOfType("string"),
+						AnythingOfType("storage.Owner"),
 					},
 					Response: []interface{}{
-						storage.NewErrNothingToDelete("dummy"),
+						storage.NewError(storage.ErrNotFound, "DeleteHero", "1", nil),
 					},
 				},
 			},
@@ -374,6 +391,32 @@ func TestHeroHandler_DeleteHeroHandler(t *testing.T) {
 				code: http.StatusNotFound,
 			},
 		},
+		{
+			name:  "should ignore missing hero with must_exist=false",
+			query: "?must_exist=false",
+			storage: []TestifyMockCall{
+				{
+					Method: "DeleteHero",
+					Call: []interface{}{
+						AnythingOfType("string"),
+						AnythingOfType("storage.Owner"),
+					},
+					Response: []interface{}{
+						storage.NewError(storage.ErrNotFound, "DeleteHero", "1", nil),
+					},
+				},
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+			},
+		},
+		{
+			name:  "should return bad request on invalid must_exist",
+			query: "?must_exist=maybe",
+			expected: expected{
+				code: http.StatusBadRequest,
+			},
+		},
 		{
 			name: "should return internal server error",
 			storage: []TestifyMockCall{
@@ -381,6 +424,7 @@ func TestHeroHandler_DeleteHeroHandler(t *testing.T) {
 					Method: "DeleteHero",
 					Call: []interface{}{
 						AnythingOfType("string"),
+						AnythingOfType("storage.Owner"),
 					},
 					Response: []interface{}{
 						errors.New("dummy"),
@@ -398,6 +442,7 @@ func TestHeroHandler_DeleteHeroHandler(t *testing.T) {
 					Method: "DeleteHero",
 					Call: []interface{}{
 						AnythingOfType("string"),
+						AnythingOfType("storage.Owner"),
 					},
 					Response: []interface{}{
 						nil,
@@ -411,7 +456,7 @@ func TestHeroHandler_DeleteHeroHandler(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			req, err := http.NewRequest(http.MethodDelete, "/hero/1", nil)
+			req, err := http.NewRequest(http.MethodDelete, "/hero/1"+tt.query, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -420,7 +465,7 @@ func TestHeroHandler_DeleteHeroHandler(t *testing.T) {
 
 			s := new(stmocks.Storager)
 			for _, mockCall := range tt.storage {
-				s.On(mockCall.Method, mockCall.Call...).Return(mockCall.Response...)
+				s.On(mockCall.Method, mockCall.args()...).Return(mockCall.Response...)
 			}
 
 			hh := HeroHandler{}
@@ -437,3 +482,367 @@ func TestHeroHandler_DeleteHeroHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestHeroHandler_UpdateHeroHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		reader   io.Reader
+		storage  []TestifyMockCall
+		expected expected
+	}{
+		{
+			name:   "should return error from ioutil.ReadAll",
+			reader: errReader(0),
+			expected: expected{
+				code: http.StatusInternalServerError,
+			},
+		},
+		{
+			name:   "should return error on invalid json",
+			reader: strings.NewReader(`{"name":`),
+			expected: expected{
+				code: http.StatusBadRequest,
+			},
+		},
+		{
+			name:   "should return error on empty name",
+			reader: strings.NewReader(`{"name":""}`),
+			expected: expected{
+				code: http.StatusBadRequest,
+			},
+		},
+		{
+			name:   "should return storage.ErrNotFound on hh.Storage.UpdateHero",
+			reader: strings.NewReader(`{"name":"Batman"}`),
+			storage: []TestifyMockCall{
+				{
+					Method: "UpdateHero",
+					Call: []interface{}{
+						"1",
+						"Batman",
+						AnythingOfType("storage.Owner"),
+					},
+					Response: []interface{}{
+						storage.NewError(storage.ErrNotFound, "UpdateHero", "1", nil),
+					},
+				},
+			},
+			expected: expected{
+				code: http.StatusNotFound,
+			},
+		},
+		{
+			name:   "should return default error on hh.Storage.UpdateHero",
+			reader: strings.NewReader(`{"name":"Batman"}`),
+			storage: []TestifyMockCall{
+				{
+					Method: "UpdateHero",
+					Call: []interface{}{
+						"1",
+						"Batman",
+						AnythingOfType("storage.Owner"),
+					},
+					Response: []interface{}{
+						errors.New("dummy"),
+					},
+				},
+			},
+			expected: expected{
+				code: http.StatusInternalServerError,
+			},
+		},
+		{
+			name:   "should update hero",
+			reader: strings.NewReader(`{"name":"Batman"}`),
+			storage: []TestifyMockCall{
+				{
+					Method: "UpdateHero",
+					Call: []interface{}{
+						"1",
+						"Batman",
+						AnythingOfType("storage.Owner"),
+					},
+					Response: []interface{}{
+						nil,
+					},
+				},
+			},
+			expected: expected{
+				code: http.StatusNoContent,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+
+			s := new(stmocks.Storager)
+			for _, mockCall := range tt.storage {
+				s.On(mockCall.Method, mockCall.args()...).Return(mockCall.Response...)
+			}
+
+			hh := HeroHandler{}
+			hh.SetStorage(s)
+
+			r := httptest.NewRequest(http.MethodPut, "/hero/1", tt.reader)
+			r = mux.SetURLVars(r, map[string]string{"id": "1"})
+			hh.UpdateHeroHandler(rr, r)
+
+			if rr.Code != tt.expected.code {
+				t.Errorf("handler returned unexpected response code: got %v want %v",
+					rr.Code, tt.expected.code)
+			}
+		})
+	}
+}
+
+func TestHeroHandler_Represent(t *testing.T) {
+	s := new(stmocks.Storager)
+	s.On("GetHero", Anything, "1").Return(storage.Hero{ID: "1", Name: "Batman"}, nil)
+
+	hh := HeroHandler{
+		Represent: func(h storage.Hero) interface{} {
+			return map[string]string{"hero_name": h.Name}
+		},
+	}
+	hh.SetStorage(s)
+
+	rr := httptest.NewRecorder()
+	r := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/v2/hero/1", nil), map[string]string{"id": "1"})
+	hh.GetHeroHandler(rr, r)
+
+	if rr.Body.String() != `{"hero_name":"Batman"}` {
+		t.Errorf("handler returned unexpected body: got %v", rr.Body.String())
+	}
+}
+
+func TestHeroHandler_Negotiation(t *testing.T) {
+	tests := []struct {
+		name        string
+		target      string
+		vars        map[string]string
+		codec       codec.Codec
+		handler     func(hh *HeroHandler) http.HandlerFunc
+		code        int
+		contentType string
+		body        string
+	}{
+		{
+			name:        "should return heroes as csv",
+			target:      "/heroes",
+			codec:       codec.CSV,
+			handler:     func(hh *HeroHandler) http.HandlerFunc { return hh.GetHeroesHandler },
+			code:        http.StatusOK,
+			contentType: "text/csv",
+			body:        "id,name,owner\n1,Batman,\n",
+		},
+		{
+			name:        "should return hero as xml",
+			target:      "/hero/1",
+			vars:        map[string]string{"id": "1"},
+			codec:       codec.XML,
+			handler:     func(hh *HeroHandler) http.HandlerFunc { return hh.GetHeroHandler },
+			code:        http.StatusOK,
+			contentType: "application/xml",
+			body:        "<hero><id>1</id><name>Batman</name></hero>",
+		},
+		{
+			name:        "should not return single hero as csv",
+			target:      "/hero/1",
+			vars:        map[string]string{"id": "1"},
+			codec:       codec.CSV,
+			handler:     func(hh *HeroHandler) http.HandlerFunc { return hh.GetHeroHandler },
+			code:        http.StatusNotAcceptable,
+			contentType: "application/problem+json",
+			body:        `{"type":"about:blank","title":"Not Acceptable","status":406,"detail":"value can't be represented in requested media type","instance":"/hero/1"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := new(stmocks.Storager)
+			s.On("GetHeroes", Anything).Return([]storage.Hero{{ID: "1", Name: "Batman"}}, nil)
+			s.On("GetHero", Anything, "1").Return(storage.Hero{ID: "1", Name: "Batman"}, nil)
+
+			hh := HeroHandler{}
+			hh.SetStorage(s)
+
+			rr := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			r = mux.SetURLVars(r.WithContext(codec.WithResponse(r.Context(), tt.codec)), tt.vars)
+			tt.handler(&hh)(rr, r)
+
+			assert.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.contentType, rr.Header().Get("Content-Type"))
+			assert.Equal(t, tt.body, rr.Body.String())
+		})
+	}
+}
+
+func TestHeroHandler_CreateHeroHandler_YAML(t *testing.T) {
+	s := new(stmocks.Storager)
+	s.On("CreateHero", Anything, "1", "Batman", storage.Owner{}).Return(nil)
+
+	hh := HeroHandler{}
+	hh.SetStorage(s)
+
+	rr := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/hero", strings.NewReader("id: \"1\"\nname: Batman\n"))
+	r = r.WithContext(codec.WithRequest(r.Context(), codec.YAML))
+	hh.CreateHeroHandler(rr, r)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	s.AssertExpectations(t)
+}
+
+func TestHeroHandler_RequestContext(t *testing.T) {
+	type ctxKey struct{}
+
+	s := new(stmocks.Storager)
+	s.On("GetHero", MatchedBy(func(ctx context.Context) bool {
+		return ctx.Value(ctxKey{}) == "request"
+	}), "1").Return(storage.Hero{ID: "1", Name: "Batman"}, nil)
+
+	hh := HeroHandler{}
+	hh.SetStorage(s)
+
+	rr := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/hero/1", nil)
+	r = mux.SetURLVars(r.WithContext(context.WithValue(r.Context(), ctxKey{}, "request")), map[string]string{"id": "1"})
+	hh.GetHeroHandler(rr, r)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	s.AssertExpectations(t)
+}
+
+func TestHeroHandler_LogsRequestID(t *testing.T) {
+	s := new(stmocks.Storager)
+	s.On("GetHero", Anything, "1").Return(storage.Hero{}, errors.New("connection refused"))
+
+	var buf bytes.Buffer
+	hh := HeroHandler{}
+	hh.SetLogger(zerolog.New(&buf))
+	hh.SetStorage(s)
+
+	rr := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/hero/1", nil)
+	r = r.WithContext(requestid.NewContext(r.Context(), "abc", hh.Logger))
+	hh.GetHeroHandler(rr, mux.SetURLVars(r, map[string]string{"id": "1"}))
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"request_id":"abc"`)
+	assert.Contains(t, buf.String(), `"request_id":"abc"`)
+	assert.Contains(t, buf.String(), "connection refused")
+}
+
+func TestHeroHandler_Ownership(t *testing.T) {
+	p := auth.Principal{Subject: "apikey:1", Scopes: []string{auth.ScopeWrite}}
+	owner := storage.Owner{ID: "apikey:1"}
+
+	s := new(stmocks.Storager)
+	s.On("CreateHero", Anything, "1", "Batman", owner).Return(nil)
+	s.On("DeleteHero", Anything, "2", owner).Return(storage.NewError(storage.ErrForbidden, "DeleteHero", "2", nil))
+
+	hh := HeroHandler{}
+	hh.SetStorage(s)
+
+	rr := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/hero", strings.NewReader(`{"id":"1","name":"Batman","owner":"apikey:2"}`))
+	hh.CreateHeroHandler(rr, r.WithContext(auth.NewContext(r.Context(), p)))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodDelete, "/hero/2", nil)
+	r = mux.SetURLVars(r.WithContext(auth.NewContext(r.Context(), p)), map[string]string{"id": "2"})
+	hh.DeleteHeroHandler(rr, r)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Equal(t, problem.MediaType, rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), "hero 2 is owned by other client")
+
+	s.AssertExpectations(t)
+}
+
+func TestHeroHandler_OwnerVisibility(t *testing.T) {
+	tests := []struct {
+		name      string
+		principal auth.Principal
+		expected  string
+	}{
+		{
+			name:      "should show owner to owner",
+			principal: auth.Principal{Subject: "apikey:1", Scopes: []string{auth.ScopeRead}},
+			expected:  `{"id":"1","name":"Batman","owner":"apikey:1"}`,
+		},
+		{
+			name:      "should show owner to admin",
+			principal: auth.Principal{Subject: "admin", Scopes: []string{auth.ScopeAdmin}},
+			expected:  `{"id":"1","name":"Batman","owner":"apikey:1"}`,
+		},
+		{
+			name:      "should hide owner from other client",
+			principal: auth.Principal{Subject: "apikey:2", Scopes: []string{auth.ScopeRead}},
+			expected:  `{"id":"1","name":"Batman"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := new(stmocks.Storager)
+			s.On("GetHero", Anything, "1").Return(storage.Hero{ID: "1", Name: "Batman", Owner: "apikey:1"}, nil)
+
+			hh := HeroHandler{}
+			hh.SetStorage(s)
+
+			rr := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/hero/1", nil)
+			r = mux.SetURLVars(r.WithContext(auth.NewContext(r.Context(), tt.principal)), map[string]string{"id": "1"})
+			hh.GetHeroHandler(rr, r)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tt.expected, rr.Body.String())
+		})
+	}
+}
+
+func TestHeroHandler_GetMyHeroesHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		heroes   []storage.Hero
+		err      error
+		code     int
+		expected string
+	}{
+		{
+			name:     "should return heroes of client",
+			heroes:   []storage.Hero{{ID: "1", Name: "Batman", Owner: "apikey:1"}},
+			code:     http.StatusOK,
+			expected: `[{"id":"1","name":"Batman","owner":"apikey:1"}]`,
+		},
+		{
+			name: "should return no content",
+			code: http.StatusNoContent,
+		},
+		{
+			name: "should return error hh.Storage.GetHeroesByOwner",
+			err:  errors.New("dummy"),
+			code: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := new(stmocks.Storager)
+			s.On("GetHeroesByOwner", Anything, "apikey:1").Return(tt.heroes, tt.err)
+
+			hh := HeroHandler{}
+			hh.SetStorage(s)
+
+			rr := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/me/heroes", nil)
+			r = r.WithContext(auth.NewContext(r.Context(), auth.Principal{Subject: "apikey:1"}))
+			hh.GetMyHeroesHandler(rr, r)
+
+			assert.Equal(t, tt.code, rr.Code)
+			if tt.expected != "" {
+				assert.JSONEq(t, tt.expected, rr.Body.String())
+			}
+		})
+	}
+}
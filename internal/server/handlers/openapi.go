@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"net/http"
+)
+
+// OpenAPIHandler serves OpenAPI document
+type OpenAPIHandler struct {
+	Spec []byte
+}
+
+// GetOpenAPIHandler handler to get OpenAPI document
+func (oh *OpenAPIHandler) GetOpenAPIHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(oh.Spec)
+}
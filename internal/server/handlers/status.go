@@ -2,36 +2,57 @@ package handlers
 
 import (
 	"net/http"
+
+	"github.com/bliuchak/heroes/internal/server/problem"
+	"github.com/bliuchak/heroes/internal/storage"
+	"github.com/bliuchak/heroes/internal/version"
 )
 
 // StatusHandler contains status handler data
 // extend common handler
 type StatusHandler struct {
 	CommonHandler
+	// Ready reports whether server accepts new requests, if un-set server
+	// is considered ready
+	Ready func() bool
 }
 
 // StatusResponse displays status of required app dependencies (e.g. storage)
 type StatusResponse struct {
-	Redis string `json:"redis"`
+	Redis   string            `json:"redis"`
+	Storage map[string]uint64 `json:"storage,omitempty"`
+	Version string            `json:"version"`
+	Commit  string            `json:"commit,omitempty"`
+	// Uptime is number of seconds since app started
+	Uptime int64 `json:"uptime"`
 }
 
 // GetStatusHandler handle for application status endpoint
-func (sh *StatusHandler) GetStatusHandler(w http.ResponseWriter, _ *http.Request) {
-	status, err := sh.Storage.Status()
+func (sh *StatusHandler) GetStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if sh.Ready != nil && !sh.Ready() {
+		problem.Write(w, r, problem.New(http.StatusServiceUnavailable, "server is shutting down"))
+		return
+	}
+
+	status, err := sh.Storage.Status(r.Context())
 	if err != nil {
-		sh.Logger.Error().Err(err).Msg("Unable to get storage status")
-		w.WriteHeader(http.StatusInternalServerError)
+		sh.writeError(w, r, err, "Unable to get storage status")
 		return
 	}
 
 	resp := StatusResponse{
-		Redis: status,
+		Redis:   status,
+		Version: version.Version,
+		Commit:  version.Commit,
+		Uptime:  int64(version.Uptime().Seconds()),
+	}
+	if sr, ok := sh.Storage.(storage.StatsReporter); ok {
+		resp.Storage = sr.Stats()
 	}
 
 	data, err := sh.Marshal(resp)
 	if err != nil {
-		sh.Logger.Error().Err(err).Msg("Unable to unmarshall data")
-		w.WriteHeader(http.StatusInternalServerError)
+		sh.writeError(w, r, err, "Unable to marshall data")
 		return
 	}
 
@@ -16,6 +16,7 @@ func TestStatusHandler_GetStatusHandler(t *testing.T) {
 		reader    io.Reader
 		storage   []TestifyMockCall
 		marshaler func(v interface{}) ([]byte, error)
+		ready     func() bool
 		expected  expected
 	}{
 		{
@@ -66,15 +67,22 @@ func TestStatusHandler_GetStatusHandler(t *testing.T) {
 				code: http.StatusInternalServerError,
 			},
 		},
+		{
+			name:  "should return service unavailable when server is shutting down",
+			ready: func() bool { return false },
+			expected: expected{
+				code: http.StatusServiceUnavailable,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			sh := StatusHandler{}
+			sh := StatusHandler{Ready: tt.ready}
 			rr := httptest.NewRecorder()
 
 			s := new(stmocks.Storager)
 			for _, mockCall := range tt.storage {
-				s.On(mockCall.Method, mockCall.Call...).Return(mockCall.Response...)
+				s.On(mockCall.Method, mockCall.args()...).Return(mockCall.Response...)
 			}
 
 			sh.SetStorage(s)
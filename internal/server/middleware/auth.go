@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/bliuchak/heroes/internal/auth"
+	"github.com/bliuchak/heroes/internal/server/problem"
+	"github.com/bliuchak/heroes/internal/server/requestid"
+	"github.com/bliuchak/heroes/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+// APIKeyHeader is header which carries API key
+const APIKeyHeader = "X-API-Key"
+
+// Authenticator authenticates request by single type of credentials
+type Authenticator interface {
+	// Authenticate returns principal of request, ok is false when request
+	// doesn't carry credentials of this type
+	Authenticate(r *http.Request) (p auth.Principal, ok bool, err error)
+	// Challenge is sent in WWW-Authenticate header of 401 response
+	Challenge() string
+}
+
+// Authenticators returns authenticators of API keys and, when verifier is
+// set, of JWT, HTTP and gRPC servers accept the same credentials
+func Authenticators(keys storage.KeyStorager, adminKey string, verifier *auth.JWTVerifier) []Authenticator {
+	authenticators := []Authenticator{&APIKeyAuth{Keys: keys, AdminKey: adminKey}}
+	if verifier != nil {
+		authenticators = append(authenticators, &JWTAuth{Verifier: verifier})
+	}
+	return authenticators
+}
+
+// Authenticate returns middleware which passes principal of request to
+// handlers via request context, request is authenticated by the first
+// authenticator which finds its credentials in it. Requests without valid
+// credentials are answered with 401.
+func Authenticate(logger zerolog.Logger, authenticators ...Authenticator) func(http.Handler) http.Handler {
+	challenges := make([]string, len(authenticators))
+	for i, a := range authenticators {
+		challenges[i] = a.Challenge()
+	}
+	challenge := strings.Join(challenges, ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			for _, a := range authenticators {
+				p, ok, err := a.Authenticate(r)
+				if err != nil {
+					pr := problem.FromError(err)
+					if pr.Status == http.StatusUnauthorized {
+						w.Header().Set("WWW-Authenticate", challenge)
+					}
+					if pr.Status >= http.StatusInternalServerError {
+						requestid.Logger(r.Context(), logger).Error().Err(err).Msg("Unable to authenticate request")
+					}
+					problem.Write(w, r, pr)
+					return
+				}
+				if ok {
+					attribute(r, p)
+					next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), p)))
+					return
+				}
+			}
+
+			w.Header().Set("WWW-Authenticate", challenge)
+			problem.Write(w, r, problem.New(http.StatusUnauthorized, "credentials are missing"))
+		})
+	}
+}
+
+// APIKeyAuth authenticates requests by API key sent in X-API-Key header
+type APIKeyAuth struct {
+	Keys storage.KeyStorager
+	// AdminKey is accepted with admin scope without lookup in storage,
+	// it's used to create the first keys, empty value disables it
+	AdminKey string
+}
+
+// Authenticate implements Authenticator
+func (a *APIKeyAuth) Authenticate(r *http.Request) (auth.Principal, bool, error) {
+	token := r.Header.Get(APIKeyHeader)
+	if token == "" {
+		return auth.Principal{}, false, nil
+	}
+
+	if a.AdminKey != "" && subtle.ConstantTimeCompare([]byte(token), []byte(a.AdminKey)) == 1 {
+		return auth.Principal{Subject: "admin", Scopes: []string{auth.ScopeAdmin}}, true, nil
+	}
+
+	id, secret, ok := auth.ParseToken(token)
+	if !ok || a.Keys == nil {
+		return auth.Principal{}, false, errUnauthorized("API key is invalid")
+	}
+
+	key, err := a.Keys.GetAPIKey(r.Context(), id)
+	if err != nil {
+		// revoked and unknown keys are reported the same way as wrong secret
+		if errors.Is(err, storage.ErrNotFound) {
+			return auth.Principal{}, false, errUnauthorized("API key is invalid")
+		}
+		return auth.Principal{}, false, err
+	}
+	if !auth.VerifySecret(key, secret) {
+		return auth.Principal{}, false, errUnauthorized("API key is invalid")
+	}
+
+	return auth.Principal{Subject: auth.SubjectPrefixAPIKey + key.ID, Scopes: key.Scopes}, true, nil
+}
+
+// Challenge implements Authenticator
+func (a *APIKeyAuth) Challenge() string {
+	return `APIKey realm="heroes"`
+}
+
+// JWTAuth authenticates requests by JWT sent as bearer token in
+// Authorization header
+type JWTAuth struct {
+	Verifier *auth.JWTVerifier
+}
+
+// Authenticate implements Authenticator
+func (a *JWTAuth) Authenticate(r *http.Request) (auth.Principal, bool, error) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return auth.Principal{}, false, nil
+	}
+
+	p, err := a.Verifier.Verify(strings.TrimSpace(token))
+	if err != nil {
+		return auth.Principal{}, false, errUnauthorized(err.Error())
+	}
+	return p, true, nil
+}
+
+// Challenge implements Authenticator
+func (a *JWTAuth) Challenge() string {
+	return `Bearer realm="heroes"`
+}
+
+// Anonymous middleware lets every request in with admin scope,
+// it's used instead of authentication when auth is disabled
+func Anonymous(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attribute(r, auth.Anonymous)
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(r.Context(), auth.Anonymous)))
+	})
+}
+
+// RequireScope returns middleware which answers 403 to requests of
+// principals without given scope and 401 to unauthenticated requests
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := auth.FromContext(r.Context())
+			if !ok {
+				problem.Write(w, r, problem.New(http.StatusUnauthorized, "request is not authenticated"))
+				return
+			}
+			if !p.HasScope(scope) {
+				problem.Write(w, r, problem.New(http.StatusForbidden, "scope "+scope+" is required"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func errUnauthorized(detail string) *problem.Problem {
+	return problem.New(http.StatusUnauthorized, detail)
+}
@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/auth"
+	"github.com/bliuchak/heroes/internal/storage"
+	stmocks "github.com/bliuchak/heroes/internal/storage/mocks"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuthenticate_APIKey(t *testing.T) {
+	key, token, err := auth.NewAPIKey("ci", []string{auth.ScopeRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := new(stmocks.KeyStorager)
+	keys.On("GetAPIKey", mock.Anything, key.ID).Return(key, nil)
+	keys.On("GetAPIKey", mock.Anything, "revoked").Return(storage.APIKey{}, storage.NewError(storage.ErrNotFound, "GetAPIKey", "revoked", nil))
+	keys.On("GetAPIKey", mock.Anything, "broken").Return(storage.APIKey{}, storage.NewError(storage.ErrUnavailable, "GetAPIKey", "broken", errors.New("EOF")))
+
+	authenticate := Authenticate(zerolog.Nop(), &APIKeyAuth{Keys: keys, AdminKey: "root"})
+
+	tests := []struct {
+		name    string
+		key     string
+		code    int
+		subject string
+	}{
+		{name: "should reject request without key", code: http.StatusUnauthorized},
+		{name: "should reject malformed key", key: "abc", code: http.StatusUnauthorized},
+		{name: "should reject key with wrong secret", key: key.ID + ".wrong", code: http.StatusUnauthorized},
+		{name: "should reject revoked key", key: "revoked.secret", code: http.StatusUnauthorized},
+		{name: "should report storage failure", key: "broken.secret", code: http.StatusServiceUnavailable},
+		{name: "should accept valid key", key: token, code: http.StatusOK, subject: "apikey:" + key.ID},
+		{name: "should accept admin key", key: "root", code: http.StatusOK, subject: "admin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var subject string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				p, _ := auth.FromContext(r.Context())
+				subject = p.Subject
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/v1/heroes", nil)
+			if tt.key != "" {
+				r.Header.Set(APIKeyHeader, tt.key)
+			}
+			rr := httptest.NewRecorder()
+			authenticate(next).ServeHTTP(rr, r)
+
+			assert.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.subject, subject)
+			if tt.code == http.StatusUnauthorized {
+				assert.NotEmpty(t, rr.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestRequireScope(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := RequireScope(auth.ScopeWrite)(next)
+
+	tests := []struct {
+		name      string
+		principal *auth.Principal
+		code      int
+	}{
+		{name: "should reject unauthenticated request", code: http.StatusUnauthorized},
+		{name: "should reject principal without scope", principal: &auth.Principal{Scopes: []string{auth.ScopeRead}}, code: http.StatusForbidden},
+		{name: "should accept principal with scope", principal: &auth.Principal{Scopes: []string{auth.ScopeWrite}}, code: http.StatusOK},
+		{name: "should accept admin", principal: &auth.Principal{Scopes: []string{auth.ScopeAdmin}}, code: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodDelete, "/v1/hero/1", nil)
+			if tt.principal != nil {
+				r = r.WithContext(auth.NewContext(r.Context(), *tt.principal))
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, r)
+
+			assert.Equal(t, tt.code, rr.Code)
+		})
+	}
+}
+
+func TestAuthenticate_JWT(t *testing.T) {
+	secret := []byte("secret")
+	keys := auth.NewKeySet()
+	keys.Add("", secret)
+	verifier, err := auth.NewJWTVerifier(keys, auth.JWTOptions{Issuer: "https://id.example.com", Audience: "heroes"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sign := func(claims jwt.MapClaims) string {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	valid := jwt.MapClaims{
+		"sub":   "alice",
+		"iss":   "https://id.example.com",
+		"aud":   "heroes",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{auth.RoleEditor},
+	}
+	expired := jwt.MapClaims{"sub": "alice", "iss": "https://id.example.com", "aud": "heroes", "exp": time.Now().Add(-time.Hour).Unix()}
+
+	authenticate := Authenticate(zerolog.Nop(), &APIKeyAuth{}, &JWTAuth{Verifier: verifier})
+
+	tests := []struct {
+		name          string
+		authorization string
+		code          int
+		subject       string
+	}{
+		{name: "should reject request without credentials", code: http.StatusUnauthorized},
+		{name: "should ignore other schemes", authorization: "Basic YWxpY2U6c2VjcmV0", code: http.StatusUnauthorized},
+		{name: "should reject expired token", authorization: "Bearer " + sign(expired), code: http.StatusUnauthorized},
+		{name: "should accept valid token", authorization: "Bearer " + sign(valid), code: http.StatusOK, subject: "jwt:alice"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var p auth.Principal
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				p, _ = auth.FromContext(r.Context())
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/v1/heroes", nil)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			rr := httptest.NewRecorder()
+			authenticate(next).ServeHTTP(rr, r)
+
+			assert.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.subject, p.Subject)
+			if tt.code == http.StatusUnauthorized {
+				assert.Equal(t, `APIKey realm="heroes", Bearer realm="heroes"`, rr.Header().Get("WWW-Authenticate"))
+			} else {
+				assert.True(t, p.HasScope(auth.ScopeWrite))
+			}
+		})
+	}
+}
+
+func TestHTTPLogger_Subject(t *testing.T) {
+	var buf bytes.Buffer
+	md := Middleware{Logger: zerolog.New(&buf)}
+
+	h := md.HTTPLogger(Anonymous(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/heroes", nil))
+
+	assert.Contains(t, buf.String(), `"subject":"anonymous"`)
+}
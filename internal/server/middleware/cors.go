@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSOptions describes which cross-origin requests are allowed
+type CORSOptions struct {
+	// AllowedOrigins are origins like "https://app.example.com", origin may
+	// have wildcard subdomain, e.g. "https://*.example.com", "*" allows any
+	// origin
+	AllowedOrigins []string
+	AllowedMethods []string
+	// AllowedHeaders are request headers allowed in addition to CORS
+	// safelisted ones, "*" allows any header
+	AllowedHeaders []string
+	// ExposedHeaders are response headers readable by browser scripts
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// MaxAge is how long preflight response may be cached, 0 leaves it
+	// to browser
+	MaxAge time.Duration
+}
+
+// CORS middleware answers preflight requests and adds CORS headers to
+// responses of allowed origins. It has to wrap router, because routes don't
+// match OPTIONS requests and preflight would be answered with 405.
+func CORS(opts CORSOptions) func(http.Handler) http.Handler {
+	methods := strings.Join(opts.AllowedMethods, ", ")
+	exposed := strings.Join(opts.ExposedHeaders, ", ")
+	maxAge := strconv.FormatInt(int64(opts.MaxAge/time.Second), 10)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			h := w.Header()
+			h.Add("Vary", "Origin")
+			if preflight {
+				h.Add("Vary", "Access-Control-Request-Method")
+				h.Add("Vary", "Access-Control-Request-Headers")
+			}
+
+			if origin == "" || !opts.originAllowed(origin) {
+				if preflight {
+					// browser refuses actual request without CORS headers
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// any origin is never allowed to send credentials, otherwise
+			// every site could call API on behalf of logged in user
+			if opts.anyOrigin() {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+				if opts.AllowCredentials {
+					h.Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+
+			if !preflight {
+				if exposed != "" {
+					h.Set("Access-Control-Expose-Headers", exposed)
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			reqHeaders := r.Header.Get("Access-Control-Request-Headers")
+			if opts.methodAllowed(r.Header.Get("Access-Control-Request-Method")) && opts.headersAllowed(reqHeaders) {
+				h.Set("Access-Control-Allow-Methods", methods)
+				if reqHeaders != "" {
+					h.Set("Access-Control-Allow-Headers", reqHeaders)
+				}
+				if opts.MaxAge > 0 {
+					h.Set("Access-Control-Max-Age", maxAge)
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
+
+// Validate reports options which would let any site make credentialed
+// requests
+func (o CORSOptions) Validate() error {
+	if o.AllowCredentials && o.anyOrigin() {
+		return errors.New("credentials can't be allowed to any origin, list allowed origins instead of *")
+	}
+	return nil
+}
+
+func (o CORSOptions) anyOrigin() bool {
+	for _, allowed := range o.AllowedOrigins {
+		if allowed == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// originAllowed reports whether origin matches one of allowed origins,
+// wildcard matches one or more subdomains but not domain itself
+func (o CORSOptions) originAllowed(origin string) bool {
+	origin = strings.ToLower(origin)
+	for _, allowed := range o.AllowedOrigins {
+		allowed = strings.ToLower(allowed)
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+
+		prefix, suffix, ok := strings.Cut(allowed, "*")
+		if !ok || !strings.HasPrefix(suffix, ".") {
+			continue
+		}
+		if len(origin) > len(prefix)+len(suffix) && strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+			sub := origin[len(prefix) : len(origin)-len(suffix)]
+			if !strings.ContainsAny(sub, "/:") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (o CORSOptions) methodAllowed(method string) bool {
+	for _, m := range o.AllowedMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// headersAllowed reports whether every header of comma separated list
+// is allowed
+func (o CORSOptions) headersAllowed(headers string) bool {
+	for _, header := range strings.Split(headers, ",") {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+
+		allowed := false
+		for _, a := range o.AllowedHeaders {
+			if a == "*" || strings.EqualFold(a, header) {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,185 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORSOptions_OriginAllowed(t *testing.T) {
+	opts := CORSOptions{AllowedOrigins: []string{"https://app.example.com", "https://*.heroes.dev"}}
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{origin: "https://app.example.com", allowed: true},
+		{origin: "https://APP.example.com", allowed: true},
+		{origin: "http://app.example.com"},
+		{origin: "https://evil.example.com"},
+		{origin: "https://admin.heroes.dev", allowed: true},
+		{origin: "https://a.b.heroes.dev", allowed: true},
+		{origin: "https://heroes.dev"},
+		{origin: "https://evilheroes.dev"},
+		{origin: "https://admin.heroes.dev:8080"},
+		{origin: "https://heroes.dev.evil.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			assert.Equal(t, tt.allowed, opts.originAllowed(tt.origin))
+		})
+	}
+
+	assert.True(t, CORSOptions{AllowedOrigins: []string{"*"}}.originAllowed("https://any.com"))
+}
+
+func TestCORS(t *testing.T) {
+	opts := CORSOptions{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{http.MethodGet, http.MethodDelete},
+		AllowedHeaders: []string{"Content-Type", "X-API-Key"},
+		ExposedHeaders: []string{"RateLimit-Remaining"},
+		MaxAge:         10 * time.Minute,
+	}
+
+	tests := []struct {
+		name    string
+		opts    CORSOptions
+		method  string
+		headers map[string]string
+		code    int
+		// expected response headers, empty value means that header is not set
+		expected map[string]string
+	}{
+		{
+			name:    "should answer preflight",
+			method:  http.MethodOptions,
+			headers: map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "DELETE", "Access-Control-Request-Headers": "x-api-key"},
+			code:    http.StatusNoContent,
+			expected: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Methods":     "GET, DELETE",
+				"Access-Control-Allow-Headers":     "x-api-key",
+				"Access-Control-Max-Age":           "600",
+				"Access-Control-Allow-Credentials": "",
+			},
+		},
+		{
+			name:    "should refuse preflight of method which is not allowed",
+			method:  http.MethodOptions,
+			headers: map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "PUT"},
+			code:    http.StatusNoContent,
+			expected: map[string]string{
+				"Access-Control-Allow-Methods": "",
+			},
+		},
+		{
+			name:    "should refuse preflight of header which is not allowed",
+			method:  http.MethodOptions,
+			headers: map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "GET", "Access-Control-Request-Headers": "X-API-Key, X-Secret"},
+			code:    http.StatusNoContent,
+			expected: map[string]string{
+				"Access-Control-Allow-Methods": "",
+				"Access-Control-Allow-Headers": "",
+			},
+		},
+		{
+			name:    "should refuse preflight of origin which is not allowed",
+			method:  http.MethodOptions,
+			headers: map[string]string{"Origin": "https://evil.com", "Access-Control-Request-Method": "GET"},
+			code:    http.StatusNoContent,
+			expected: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:    "should add headers to actual request",
+			method:  http.MethodGet,
+			headers: map[string]string{"Origin": "https://app.example.com"},
+			code:    http.StatusTeapot,
+			expected: map[string]string{
+				"Access-Control-Allow-Origin":   "https://app.example.com",
+				"Access-Control-Expose-Headers": "RateLimit-Remaining",
+				"Vary":                          "Origin",
+			},
+		},
+		{
+			name:   "should pass same origin request",
+			method: http.MethodGet,
+			code:   http.StatusTeapot,
+			expected: map[string]string{
+				"Access-Control-Allow-Origin": "",
+			},
+		},
+		{
+			name:   "should pass OPTIONS request which is not preflight",
+			method: http.MethodOptions,
+			code:   http.StatusTeapot,
+		},
+		{
+			name:    "should allow any origin",
+			opts:    CORSOptions{AllowedOrigins: []string{"*"}},
+			method:  http.MethodGet,
+			headers: map[string]string{"Origin": "https://any.com"},
+			code:    http.StatusTeapot,
+			expected: map[string]string{
+				"Access-Control-Allow-Origin": "*",
+			},
+		},
+		{
+			name:    "should echo origin when credentials are allowed",
+			opts:    CORSOptions{AllowedOrigins: []string{"https://*.example.com"}, AllowCredentials: true},
+			method:  http.MethodGet,
+			headers: map[string]string{"Origin": "https://app.example.com"},
+			code:    http.StatusTeapot,
+			expected: map[string]string{
+				"Access-Control-Allow-Origin":      "https://app.example.com",
+				"Access-Control-Allow-Credentials": "true",
+			},
+		},
+		{
+			name:    "should not allow credentials to any origin",
+			opts:    CORSOptions{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+			method:  http.MethodGet,
+			headers: map[string]string{"Origin": "https://evil.com"},
+			code:    http.StatusTeapot,
+			expected: map[string]string{
+				"Access-Control-Allow-Origin":      "*",
+				"Access-Control-Allow-Credentials": "",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			})
+
+			o := opts
+			if tt.opts.AllowedOrigins != nil {
+				o = tt.opts
+			}
+
+			r := httptest.NewRequest(tt.method, "/v1/hero/1", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			CORS(o)(next).ServeHTTP(rr, r)
+
+			assert.Equal(t, tt.code, rr.Code)
+			for k, v := range tt.expected {
+				assert.Equal(t, v, rr.Header().Get(k), k)
+			}
+		})
+	}
+}
+
+func TestCORSOptions_Validate(t *testing.T) {
+	assert.Error(t, CORSOptions{AllowedOrigins: []string{"https://app.example.com", "*"}, AllowCredentials: true}.Validate())
+	assert.NoError(t, CORSOptions{AllowedOrigins: []string{"*"}}.Validate())
+	assert.NoError(t, CORSOptions{AllowedOrigins: []string{"https://*.example.com"}, AllowCredentials: true}.Validate())
+}
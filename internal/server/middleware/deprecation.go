@@ -0,0 +1,35 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Deprecated middleware marks responses of deprecated routes with Deprecation
+// (RFC 9745) and Sunset (RFC 8594) headers, Link header points to the same
+// path prefixed with successor, e.g. /hero/1 -> /v1/hero/1. Successor is
+// passed via request context, so deprecated route shares rate limit with
+// its successor.
+func Deprecated(deprecatedAt, sunset time.Time, successor string) func(http.Handler) http.Handler {
+	deprecation := "@" + strconv.FormatInt(deprecatedAt.Unix(), 10)
+	sunsetDate := sunset.UTC().Format(http.TimeFormat)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Deprecation", deprecation)
+			w.Header().Set("Sunset", sunsetDate)
+			w.Header().Set("Link", "<"+successor+r.URL.Path+`>; rel="successor-version"`)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), successorKey{}, successor)))
+		})
+	}
+}
+
+type successorKey struct{}
+
+// successorOf returns prefix of successor version of deprecated route
+func successorOf(ctx context.Context) string {
+	successor, _ := ctx.Value(successorKey{}).(string)
+	return successor
+}
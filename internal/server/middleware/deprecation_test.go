@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecated(t *testing.T) {
+	deprecatedAt := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 4, 19, 0, 0, 0, 0, time.UTC)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	rr := httptest.NewRecorder()
+	Deprecated(deprecatedAt, sunset, "/v1")(next).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/hero/1", nil))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "@1792368000", rr.Header().Get("Deprecation"))
+	assert.Equal(t, "Mon, 19 Apr 2027 00:00:00 GMT", rr.Header().Get("Sunset"))
+	assert.Equal(t, `</v1/hero/1>; rel="successor-version"`, rr.Header().Get("Link"))
+}
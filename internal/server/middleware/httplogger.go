@@ -1,13 +1,221 @@
 package middleware
 
 import (
+	"context"
+	"net"
 	"net/http"
+	"net/netip"
+	"regexp"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/auth"
+	"github.com/bliuchak/heroes/internal/server/requestid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
 )
 
-// HTTPLogger middleware to log http request
-func (md *Middleware) HTTPLogger(next http.Handler) http.Handler {
+// attribution collects details of request which are known only after inner
+// middleware ran, e.g. authenticated subject or matched route
+type attribution struct {
+	subject string
+	route   string
+}
+
+type attributionKey struct{}
+
+// attributed returns request which carries attribution, attribution of
+// outer middleware is reused, so every one of them sees the same details
+func attributed(r *http.Request) (*http.Request, *attribution) {
+	if a, ok := r.Context().Value(attributionKey{}).(*attribution); ok {
+		return r, a
+	}
+	a := &attribution{}
+	return r.WithContext(context.WithValue(r.Context(), attributionKey{}, a)), a
+}
+
+// attribute records principal of request for HTTPLogger
+func attribute(r *http.Request, p auth.Principal) {
+	if a, ok := r.Context().Value(attributionKey{}).(*attribution); ok {
+		a.subject = p.Subject
+	}
+}
+
+// routeOf returns template of route matched by router
+func (a *attribution) routeOf(r *http.Request) string {
+	if a.route != "" {
+		return a.route
+	}
+	// middleware may be used by router itself
+	return templateOf(r)
+}
+
+// RecordRoute middleware records route template matched by router for
+// HTTPLogger which wraps router
+func RecordRoute(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		md.Logger.Info().Str("method", r.Method).Str("url", r.RequestURI).Msg("Middleware call")
+		if a, ok := r.Context().Value(attributionKey{}).(*attribution); ok {
+			a.route = templateOf(r)
+		}
 		next.ServeHTTP(w, r)
 	})
 }
+
+// responseWriter records status code and size of response
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	size   int64
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += int64(n)
+	return n, err
+}
+
+// statusCode returns status of response, handler which wrote nothing
+// responded with 200
+func (w *responseWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Unwrap lets http.ResponseController reach underlying writer
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// HTTPLogger middleware to log http request, request is logged after it's
+// served, so it's attributed to authenticated subject and its response
+func (md *Middleware) HTTPLogger(next http.Handler) http.Handler {
+	var served uint64
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		r, a := attributed(r)
+		rw := &responseWriter{ResponseWriter: w}
+		next.ServeHTTP(rw, r)
+
+		status := rw.statusCode()
+		if status < http.StatusBadRequest && md.Sample > 1 && atomic.AddUint64(&served, 1)%uint64(md.Sample) != 1 {
+			return
+		}
+
+		route := a.routeOf(r)
+
+		logger := requestid.Logger(r.Context(), md.Logger)
+		var ev *zerolog.Event
+		switch {
+		case status >= http.StatusInternalServerError:
+			ev = logger.Error()
+		case status >= http.StatusBadRequest:
+			ev = logger.Warn()
+		default:
+			ev = logger.Info()
+		}
+		ev = ev.Str("method", r.Method).
+			Str("url", r.RequestURI).
+			Str("route", route).
+			Int("status", status).
+			Int64("size", rw.size).
+			Dur("duration", time.Since(start)).
+			Str("remote_addr", md.remoteAddr(r)).
+			Str("user_agent", r.UserAgent())
+		if a.subject != "" {
+			ev = ev.Str("subject", a.subject)
+		}
+		ev.Msg("Request served")
+	})
+}
+
+// routeVar matches route variable with pattern, e.g. {id:[0-9]+}
+var routeVar = regexp.MustCompile(`\{([^:}]+):[^}]+\}`)
+
+// templateOf returns route template matched by router, e.g.
+// "/v1/hero/{id}", or empty string when no route matched
+func templateOf(r *http.Request) string {
+	if route := mux.CurrentRoute(r); route != nil {
+		if tpl, err := route.GetPathTemplate(); err == nil {
+			return routeVar.ReplaceAllString(tpl, "{$1}")
+		}
+	}
+	return ""
+}
+
+// remoteAddr returns IP address of client, X-Forwarded-For is followed from
+// the closest proxy as long as proxies are trusted
+func (md *Middleware) remoteAddr(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if len(md.TrustedProxies) == 0 {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0 && md.trusted(host); i-- {
+		hop := strings.TrimSpace(hops[i])
+		if _, err := netip.ParseAddr(hop); err != nil {
+			break
+		}
+		host = hop
+	}
+	return host
+}
+
+// trusted reports whether address belongs to trusted proxy
+func (md *Middleware) trusted(host string) bool {
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range md.TrustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePrefixes parses comma separated list of networks and addresses,
+// e.g. "10.0.0.0/8,192.168.1.1"
+func ParsePrefixes(s string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return nil, err
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		p, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	return prefixes, nil
+}
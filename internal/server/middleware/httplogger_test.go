@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// entries returns log entries written as JSON lines
+func entries(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	var es []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+		var e map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &e))
+		es = append(es, e)
+	}
+	return es
+}
+
+func TestHTTPLogger(t *testing.T) {
+	var buf bytes.Buffer
+	md := Middleware{Logger: zerolog.New(&buf)}
+
+	r := mux.NewRouter()
+	r.Use(RecordRoute)
+	r.HandleFunc("/v1/hero/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("Batman"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/hero/1?x=1", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("User-Agent", "heroes-test")
+	md.HTTPLogger(r).ServeHTTP(httptest.NewRecorder(), req)
+
+	es := entries(t, &buf)
+	if assert.Len(t, es, 1) {
+		e := es[0]
+		assert.Equal(t, "info", e["level"])
+		assert.Equal(t, "GET", e["method"])
+		assert.Equal(t, "/v1/hero/1?x=1", e["url"])
+		assert.Equal(t, "/v1/hero/{id}", e["route"])
+		assert.Equal(t, float64(http.StatusCreated), e["status"])
+		assert.Equal(t, float64(6), e["size"])
+		assert.Contains(t, e, "duration")
+		assert.Equal(t, "10.0.0.1", e["remote_addr"])
+		assert.Equal(t, "heroes-test", e["user_agent"])
+	}
+
+	// unmatched request is logged without route
+	buf.Reset()
+	md.HTTPLogger(r).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/unknown", nil))
+	es = entries(t, &buf)
+	if assert.Len(t, es, 1) {
+		assert.Equal(t, "warn", es[0]["level"])
+		assert.Equal(t, float64(http.StatusNotFound), es[0]["status"])
+		assert.Equal(t, "", es[0]["route"])
+	}
+}
+
+func TestHTTPLogger_Sample(t *testing.T) {
+	var buf bytes.Buffer
+	md := Middleware{Logger: zerolog.New(&buf), Sample: 3}
+
+	code := http.StatusOK
+	h := md.HTTPLogger(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+	}))
+	serve := func(n int) {
+		for i := 0; i < n; i++ {
+			h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/heroes", nil))
+		}
+	}
+
+	serve(6)
+	assert.Len(t, entries(t, &buf), 2)
+
+	// errors are always logged
+	buf.Reset()
+	code = http.StatusInternalServerError
+	serve(3)
+	es := entries(t, &buf)
+	if assert.Len(t, es, 3) {
+		assert.Equal(t, "error", es[0]["level"])
+	}
+}
+
+func TestMiddleware_RemoteAddr(t *testing.T) {
+	proxies, err := ParsePrefixes("10.0.0.0/8, 192.168.1.1")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		proxies    []netip.Prefix
+		remoteAddr string
+		xff        []string
+		expected   string
+	}{
+		{
+			name:       "should ignore X-Forwarded-For without trusted proxies",
+			remoteAddr: "10.0.0.1:1234",
+			xff:        []string{"1.2.3.4"},
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "should ignore X-Forwarded-For of untrusted client",
+			proxies:    proxies,
+			remoteAddr: "8.8.8.8:1234",
+			xff:        []string{"1.2.3.4"},
+			expected:   "8.8.8.8",
+		},
+		{
+			name:       "should take client from trusted proxy",
+			proxies:    proxies,
+			remoteAddr: "10.0.0.1:1234",
+			xff:        []string{"1.2.3.4"},
+			expected:   "1.2.3.4",
+		},
+		{
+			name:       "should follow chain of trusted proxies",
+			proxies:    proxies,
+			remoteAddr: "10.0.0.1:1234",
+			xff:        []string{"6.6.6.6, 1.2.3.4", "192.168.1.1"},
+			expected:   "1.2.3.4",
+		},
+		{
+			name:       "should stop at invalid address",
+			proxies:    proxies,
+			remoteAddr: "10.0.0.1:1234",
+			xff:        []string{"1.2.3.4, unknown"},
+			expected:   "10.0.0.1",
+		},
+		{
+			name:       "should keep proxy without X-Forwarded-For",
+			proxies:    proxies,
+			remoteAddr: "10.0.0.1:1234",
+			expected:   "10.0.0.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			md := Middleware{TrustedProxies: tt.proxies}
+			r := httptest.NewRequest(http.MethodGet, "/v1/heroes", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			assert.Equal(t, tt.expected, md.remoteAddr(r))
+		})
+	}
+}
+
+func TestParsePrefixes(t *testing.T) {
+	prefixes, err := ParsePrefixes("10.1.2.3/8,::1,")
+	assert.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("::1/128")}, prefixes)
+
+	_, err = ParsePrefixes("10.0.0.0/33")
+	assert.Error(t, err)
+	_, err = ParsePrefixes("proxy")
+	assert.Error(t, err)
+}
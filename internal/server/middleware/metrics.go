@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/metrics"
+)
+
+// unmatched labels requests which matched no route, so paths of unknown
+// routes don't create new series
+const unmatched = "unmatched"
+
+// methods are labelled as they are, other methods are labelled "other",
+// so clients can't create new series by sending arbitrary methods
+var methods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodOptions: true,
+}
+
+func methodOf(r *http.Request) string {
+	if methods[r.Method] {
+		return r.Method
+	}
+	return "other"
+}
+
+// Metrics middleware counts requests and observes their latency by route
+// template, method and status
+func Metrics(m *metrics.Metrics) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			m.HTTPInFlight.Inc()
+			defer m.HTTPInFlight.Dec()
+
+			start := time.Now()
+			r, a := attributed(r)
+			rw := &responseWriter{ResponseWriter: w}
+			next.ServeHTTP(rw, r)
+
+			route := a.routeOf(r)
+			if route == "" {
+				route = unmatched
+			}
+			method, status := methodOf(r), strconv.Itoa(rw.statusCode())
+			m.HTTPRequests.WithLabelValues(route, method, status).Inc()
+			m.HTTPDuration.WithLabelValues(route, method, status).Observe(time.Since(start).Seconds())
+		})
+	}
+}
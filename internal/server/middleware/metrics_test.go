@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bliuchak/heroes/internal/metrics"
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	m := metrics.New()
+
+	r := mux.NewRouter()
+	r.Use(RecordRoute)
+	r.HandleFunc("/v1/hero/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, float64(1), testutil.ToFloat64(m.HTTPInFlight))
+	})
+	h := Metrics(m)(r)
+
+	for _, path := range []string{"/v1/hero/1", "/v1/hero/2", "/v1/unknown"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.HTTPRequests.WithLabelValues("/v1/hero/{id}", http.MethodGet, "200")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.HTTPRequests.WithLabelValues(unmatched, http.MethodGet, "404")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.HTTPDuration))
+	assert.Equal(t, float64(0), testutil.ToFloat64(m.HTTPInFlight))
+}
+
+func TestMetrics_UnknownMethods(t *testing.T) {
+	m := metrics.New()
+	h := Metrics(m)(mux.NewRouter())
+
+	for _, method := range []string{"FOO1", "FOO2", http.MethodGet} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/x", nil))
+	}
+
+	assert.Equal(t, float64(2), testutil.ToFloat64(m.HTTPRequests.WithLabelValues(unmatched, "other", "404")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.HTTPRequests.WithLabelValues(unmatched, http.MethodGet, "404")))
+	assert.Equal(t, 2, testutil.CollectAndCount(m.HTTPRequests))
+}
@@ -1,10 +1,20 @@
 package middleware
 
-import "github.com/rs/zerolog"
+import (
+	"net/netip"
+
+	"github.com/rs/zerolog"
+)
 
 // Middleware contains dependencies for middleware structure
 type Middleware struct {
 	Logger zerolog.Logger
+	// Sample makes HTTPLogger log only every n-th successful request,
+	// failed requests are always logged, 0 logs every request
+	Sample int
+	// TrustedProxies may set X-Forwarded-For header, address of client is
+	// taken from it when request comes from one of them
+	TrustedProxies []netip.Prefix
 }
 
 // SetLogger sets logger
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/bliuchak/heroes/internal/server/codec"
+	"github.com/bliuchak/heroes/internal/server/problem"
+)
+
+// Negotiate middleware selects codec of response by Accept header and codec
+// of request body by Content-Type header, both are passed to handlers via
+// request context. Unsupported types are answered with 406 and 415.
+func Negotiate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept")
+
+		resp, ok := codec.Negotiate(r.Header.Get("Accept"))
+		if !ok {
+			problem.Write(w, r, problem.New(http.StatusNotAcceptable, "none of accepted media types is supported"))
+			return
+		}
+
+		ctx := codec.WithResponse(r.Context(), resp)
+
+		if r.ContentLength != 0 {
+			req, ok := codec.ForContentType(r.Header.Get("Content-Type"))
+			if !ok {
+				problem.Write(w, r, problem.New(http.StatusUnsupportedMediaType, "content type of request body is not supported"))
+				return
+			}
+			ctx = codec.WithRequest(ctx, req)
+		}
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
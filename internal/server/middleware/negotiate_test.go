@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bliuchak/heroes/internal/server/codec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		body        string
+		code        int
+		response    string
+		request     string
+	}{
+		{
+			name:     "should use json by default",
+			code:     http.StatusOK,
+			response: "application/json",
+			request:  "application/json",
+		},
+		{
+			name:        "should select codecs from headers",
+			accept:      "application/xml",
+			contentType: "application/yaml",
+			body:        "id: \"1\"",
+			code:        http.StatusOK,
+			response:    "application/xml",
+			request:     "application/yaml",
+		},
+		{
+			name:   "should return not acceptable",
+			accept: "text/html",
+			code:   http.StatusNotAcceptable,
+		},
+		{
+			name:        "should return unsupported media type",
+			contentType: "text/csv",
+			body:        "id,name",
+			code:        http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "should ignore content type of request without body",
+			contentType: "text/html",
+			code:        http.StatusOK,
+			response:    "application/json",
+			request:     "application/json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response, request string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				response = codec.Response(r.Context()).MediaType
+				request = codec.Request(r.Context()).MediaType
+				w.WriteHeader(http.StatusOK)
+			})
+
+			r := httptest.NewRequest(http.MethodPost, "/hero", strings.NewReader(tt.body))
+			r.Header.Set("Accept", tt.accept)
+			r.Header.Set("Content-Type", tt.contentType)
+			rr := httptest.NewRecorder()
+
+			Negotiate(next).ServeHTTP(rr, r)
+
+			assert.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.response, response)
+			assert.Equal(t, tt.request, request)
+			assert.Equal(t, "Accept", rr.Header().Get("Vary"))
+		})
+	}
+}
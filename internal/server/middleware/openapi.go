@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/bliuchak/heroes/internal/server/codec"
+	"github.com/bliuchak/heroes/internal/server/problem"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+)
+
+// prefixInvalidContentType is reason of validation error returned for request
+// body which media type is not described in document
+const prefixInvalidContentType = "header Content-Type has unexpected value"
+
+func init() {
+	// validator has no decoders for some of supported media types,
+	// they are decoded by API codecs before validation
+	for _, c := range codec.Codecs() {
+		if c.Decode == nil {
+			continue
+		}
+		for _, mt := range codec.MediaTypes(c) {
+			if openapi3filter.RegisteredBodyDecoder(mt) == nil {
+				openapi3filter.RegisterBodyDecoder(mt, bodyDecoder(c))
+			}
+		}
+	}
+}
+
+func bodyDecoder(c codec.Codec) openapi3filter.BodyDecoder {
+	return func(body io.Reader, _ http.Header, _ *openapi3.SchemaRef, _ openapi3filter.EncodingFn) (interface{}, error) {
+		data, err := ioutil.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		v, err := c.Decode(data)
+		if err != nil {
+			return nil, &openapi3filter.ParseError{Kind: openapi3filter.KindInvalidFormat, Cause: err}
+		}
+		return v, nil
+	}
+}
+
+// NewRequestValidator returns middleware which validates request parameters
+// and body against given OpenAPI document
+func NewRequestValidator(spec []byte) (func(http.Handler) http.Handler, error) {
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := doc.Validate(loader.Context); err != nil {
+		return nil, err
+	}
+
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(next http.Handler) http.Handler {
+		return requestValidator(router, next)
+	}, nil
+}
+
+// MustRequestValidator is like NewRequestValidator but panics if document is not valid
+func MustRequestValidator(spec []byte) func(http.Handler) http.Handler {
+	v, err := NewRequestValidator(spec)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func requestValidator(router routers.Router, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := router.FindRoute(r)
+		if err != nil {
+			// requests which are not described are answered by router itself
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			},
+		}
+		if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+			p := problem.Invalid(err.Error())
+			var reqErr *openapi3filter.RequestError
+			if errors.As(err, &reqErr) && strings.HasPrefix(reqErr.Reason, prefixInvalidContentType) {
+				p = problem.New(http.StatusUnsupportedMediaType, err.Error())
+			}
+			problem.Write(w, r, p)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bliuchak/heroes/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRequestValidator(t *testing.T) {
+	_, err := NewRequestValidator([]byte(`{"openapi": "3.0.3"}`))
+	assert.Error(t, err)
+
+	tests := []struct {
+		name        string
+		method      string
+		target      string
+		contentType string
+		body        string
+		code        int
+	}{
+		{
+			name:   "should pass valid hero",
+			method: http.MethodPost,
+			target: "/hero",
+			body:   `{"id":"1","name":"Batman"}`,
+			code:   http.StatusOK,
+		},
+		{
+			name:   "should reject invalid json",
+			method: http.MethodPost,
+			target: "/hero",
+			body:   `{"id":`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name:   "should reject hero without name",
+			method: http.MethodPost,
+			target: "/hero",
+			body:   `{"id":"1","name":""}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name:   "should reject not numeric id",
+			method: http.MethodPost,
+			target: "/hero",
+			body:   `{"id":"abc","name":"Batman"}`,
+			code:   http.StatusBadRequest,
+		},
+		{
+			name:        "should pass valid xml hero",
+			method:      http.MethodPost,
+			target:      "/hero",
+			contentType: "application/xml",
+			body:        `<hero><id>1</id><name>Batman</name></hero>`,
+			code:        http.StatusOK,
+		},
+		{
+			name:        "should reject xml hero without name",
+			method:      http.MethodPost,
+			target:      "/hero",
+			contentType: "text/xml",
+			body:        `<hero><id>1</id></hero>`,
+			code:        http.StatusBadRequest,
+		},
+		{
+			name:        "should reject not described content type",
+			method:      http.MethodPost,
+			target:      "/hero",
+			contentType: "text/html",
+			body:        `<p>Batman</p>`,
+			code:        http.StatusUnsupportedMediaType,
+		},
+		{
+			name:   "should reject invalid query parameter",
+			method: http.MethodDelete,
+			target: "/hero/1?must_exist=maybe",
+			code:   http.StatusBadRequest,
+		},
+		{
+			name:   "should pass not described request to router",
+			method: http.MethodGet,
+			target: "/unknown",
+			code:   http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+
+			r := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			if tt.contentType == "" {
+				tt.contentType = "application/json"
+			}
+			r.Header.Set("Content-Type", tt.contentType)
+			rr := httptest.NewRecorder()
+
+			MustRequestValidator(api.OpenAPI)(next).ServeHTTP(rr, r)
+
+			assert.Equal(t, tt.code, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/auth"
+	"github.com/bliuchak/heroes/internal/ratelimit"
+	"github.com/bliuchak/heroes/internal/server/problem"
+	"github.com/bliuchak/heroes/internal/server/requestid"
+)
+
+// RateLimit middleware limits requests of every client on every route,
+// client is identified by authenticated subject, or by IP when
+// authentication is disabled. Responses carry RateLimit-* headers, limited
+// requests are answered with 429 and Retry-After. Requests are let in when
+// limiter fails.
+func (md *Middleware) RateLimit(limiter ratelimit.Limiter, rules ratelimit.Rules) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeOf(r)
+			client := md.clientOf(r)
+
+			limit := rules.Limit(route, client)
+			res, ok := md.allow(r, limiter, route+"|"+client, client, limit)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			setLimitHeaders(w, limit, res)
+			if !res.Allowed {
+				tooManyRequests(w, r, limit, res)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimitIP middleware limits requests by IP of client on all routes
+// together before they are authenticated, so requests with invalid
+// credentials are limited too and don't reach storage of API keys. Limit is
+// coarse ceiling against abuse, it has to be well above limits of clients,
+// which share IP behind NAT or proxy. Only limited requests carry RateLimit-*
+// headers, others get them from RateLimit.
+func (md *Middleware) RateLimitIP(limiter ratelimit.Limiter, limit ratelimit.Limit) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := "ip:" + md.remoteAddr(r)
+
+			res, ok := md.allow(r, limiter, "ip|"+client, client, limit)
+			if ok && !res.Allowed {
+				setLimitHeaders(w, limit, res)
+				tooManyRequests(w, r, limit, res)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// allow takes token of request from bucket, ok is false when request isn't
+// limited or limiter failed
+func (md *Middleware) allow(r *http.Request, limiter ratelimit.Limiter, bucket, client string, limit ratelimit.Limit) (res ratelimit.Result, ok bool) {
+	if limit.IsZero() {
+		return res, false
+	}
+
+	res, err := limiter.Allow(r.Context(), bucket, limit)
+	if err != nil {
+		if r.Context().Err() == nil {
+			requestid.Logger(r.Context(), md.Logger).Error().Err(err).Str("client", client).Msg("Unable to check rate limit")
+		}
+		return res, false
+	}
+	return res, true
+}
+
+func setLimitHeaders(w http.ResponseWriter, limit ratelimit.Limit, res ratelimit.Result) {
+	h := w.Header()
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", seconds(res.Reset))
+	h.Set("RateLimit-Policy", strconv.Itoa(limit.Requests)+";w="+seconds(limit.Window))
+}
+
+func tooManyRequests(w http.ResponseWriter, r *http.Request, limit ratelimit.Limit, res ratelimit.Result) {
+	retry := seconds(res.RetryAfter)
+	w.Header().Set("Retry-After", retry)
+	problem.Write(w, r, problem.New(http.StatusTooManyRequests,
+		"rate limit of "+limit.String()+" is exceeded, retry in "+retry+"s"))
+}
+
+// routeOf returns method and template of matched route as it's written
+// in OpenAPI document, e.g. "GET /v1/hero/{id}", so every hero shares
+// the same limit. Deprecated routes are reported as their successors, so
+// aliases share buckets.
+func routeOf(r *http.Request) string {
+	if tpl := templateOf(r); tpl != "" {
+		return r.Method + " " + successorOf(r.Context()) + tpl
+	}
+	return r.Method + " " + r.URL.Path
+}
+
+// clientOf returns ID of client, e.g. "apikey:1a2b" or "ip:10.0.0.1",
+// IP is resolved the same way as in access log
+func (md *Middleware) clientOf(r *http.Request) string {
+	if p, ok := auth.FromContext(r.Context()); ok && p.Subject != "" && p.Subject != auth.Anonymous.Subject {
+		return p.Subject
+	}
+	return "ip:" + md.remoteAddr(r)
+}
+
+// seconds formats duration as whole seconds rounded up
+func seconds(d time.Duration) string {
+	s := int64(d / time.Second)
+	if d%time.Second > 0 {
+		s++
+	}
+	return strconv.FormatInt(s, 10)
+}
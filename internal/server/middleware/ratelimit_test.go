@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/auth"
+	"github.com/bliuchak/heroes/internal/ratelimit"
+	"github.com/bliuchak/heroes/internal/server/problem"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func rateLimitedRouter(limiter ratelimit.Limiter, rules ratelimit.Rules) *mux.Router {
+	md := &Middleware{Logger: zerolog.Nop()}
+	return limitedRouter(md.RateLimit(limiter, rules))
+}
+
+// limitedRouter returns router of hero routes which answer 204 behind mw
+func limitedRouter(mw ...mux.MiddlewareFunc) *mux.Router {
+	ok := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+
+	r := mux.NewRouter()
+	r.Use(mw...)
+	r.HandleFunc("/v1/heroes", ok).Methods(http.MethodGet)
+	r.HandleFunc("/v1/hero/{id:[0-9]+}", ok).Methods(http.MethodGet)
+	return r
+}
+
+func TestRateLimit(t *testing.T) {
+	rules := ratelimit.Rules{
+		Default: ratelimit.Limit{Requests: 3, Window: time.Minute},
+		Routes:  map[string]ratelimit.Limit{"GET /v1/heroes": {Requests: 1, Window: time.Minute}},
+	}
+	router := rateLimitedRouter(ratelimit.NewLocal(), rules)
+
+	get := func(path, remoteAddr, subject string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.RemoteAddr = remoteAddr
+		if subject != "" {
+			r = r.WithContext(auth.NewContext(r.Context(), auth.Principal{Subject: subject}))
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, r)
+		return rr
+	}
+
+	rr := get("/v1/heroes", "10.0.0.1:1234", "apikey:1")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", rr.Header().Get("RateLimit-Reset"))
+	assert.Equal(t, "1;w=60", rr.Header().Get("RateLimit-Policy"))
+
+	rr = get("/v1/heroes", "10.0.0.1:1234", "apikey:1")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "60", rr.Header().Get("Retry-After"))
+	assert.Equal(t, problem.MediaType, rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), "rate limit of 1/1m0s is exceeded")
+
+	// other routes and other clients have own limits
+	rr = get("/v1/hero/1", "10.0.0.1:1234", "apikey:1")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "3", rr.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, http.StatusNoContent, get("/v1/heroes", "10.0.0.1:1234", "apikey:2").Code)
+
+	// anonymous clients are told apart by IP
+	assert.Equal(t, http.StatusNoContent, get("/v1/heroes", "10.0.0.1:1234", auth.Anonymous.Subject).Code)
+	assert.Equal(t, http.StatusTooManyRequests, get("/v1/heroes", "10.0.0.1:5678", auth.Anonymous.Subject).Code)
+	assert.Equal(t, http.StatusNoContent, get("/v1/heroes", "10.0.0.2:1234", "").Code)
+}
+
+func TestRateLimit_ForwardedClient(t *testing.T) {
+	rules := ratelimit.Rules{Default: ratelimit.Limit{Requests: 1, Window: time.Minute}}
+	md := &Middleware{Logger: zerolog.Nop(), TrustedProxies: []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8")}}
+	router := limitedRouter(md.RateLimit(ratelimit.NewLocal(), rules))
+
+	get := func(remoteAddr, forwarded string) int {
+		r := httptest.NewRequest(http.MethodGet, "/v1/heroes", nil)
+		r.RemoteAddr = remoteAddr
+		r.Header.Set("X-Forwarded-For", forwarded)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, r)
+		return rr.Code
+	}
+
+	// clients behind the same proxy have own limits
+	assert.Equal(t, http.StatusNoContent, get("10.0.0.1:1234", "203.0.113.1"))
+	assert.Equal(t, http.StatusNoContent, get("10.0.0.1:1234", "203.0.113.2"))
+	assert.Equal(t, http.StatusTooManyRequests, get("10.0.0.2:1234", "203.0.113.1"))
+	// untrusted client can't pick its address
+	assert.Equal(t, http.StatusNoContent, get("198.51.100.1:1234", "203.0.113.3"))
+	assert.Equal(t, http.StatusTooManyRequests, get("198.51.100.1:1234", "203.0.113.4"))
+}
+
+func TestRateLimitIP(t *testing.T) {
+	rules := ratelimit.Rules{
+		Default: ratelimit.Limit{Requests: 1, Window: time.Minute},
+		Clients: map[string]ratelimit.Limit{"apikey:1": {Requests: 10, Window: time.Minute}},
+	}
+	limiter := ratelimit.NewLocal()
+	md := &Middleware{Logger: zerolog.Nop()}
+	router := limitedRouter(md.RateLimitIP(limiter, ratelimit.Limit{Requests: 3, Window: time.Minute}), md.RateLimit(limiter, rules))
+
+	get := func(remoteAddr, subject string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/v1/heroes", nil)
+		r.RemoteAddr = remoteAddr
+		r = r.WithContext(auth.NewContext(r.Context(), auth.Principal{Subject: subject}))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, r)
+		return rr
+	}
+
+	// limit of client applies while IP ceiling isn't reached
+	for i := 0; i < 3; i++ {
+		rr := get("10.0.0.1:1234", "apikey:1")
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+		assert.Equal(t, "10", rr.Header().Get("RateLimit-Limit"))
+	}
+	// every request from the same IP is limited before authentication then
+	rr := get("10.0.0.1:1234", "apikey:2")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "3", rr.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, http.StatusNoContent, get("10.0.0.2:1234", "apikey:2").Code)
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+func TestRateLimit_LimiterFails(t *testing.T) {
+	rules := ratelimit.Rules{Default: ratelimit.Limit{Requests: 1, Window: time.Minute}}
+	router := rateLimitedRouter(failingLimiter{}, rules)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/heroes", nil))
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Empty(t, rr.Header().Get("RateLimit-Limit"))
+}
+
+func TestRouteOf(t *testing.T) {
+	var route string
+	r := mux.NewRouter()
+	r.HandleFunc("/v1/hero/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		route = routeOf(r)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodDelete, "/v1/hero/42", nil))
+	assert.Equal(t, "DELETE /v1/hero/{id}", route)
+}
+
+func TestRouteOf_Deprecated(t *testing.T) {
+	var route string
+	r := mux.NewRouter()
+	r.Use(Deprecated(time.Now(), time.Now(), "/v1"))
+	r.HandleFunc("/hero/{id:[0-9]+}", func(w http.ResponseWriter, r *http.Request) {
+		route = routeOf(r)
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hero/42", nil))
+	assert.Equal(t, "GET /v1/hero/{id}", route)
+}
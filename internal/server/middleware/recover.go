@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/bliuchak/heroes/internal/metrics"
+	"github.com/bliuchak/heroes/internal/server/problem"
+	"github.com/bliuchak/heroes/internal/server/requestid"
+	"github.com/rs/zerolog"
+)
+
+// Recover middleware turns panic of handler into 500 problem response,
+// panic is logged with stack trace and counted. In debug mode value of panic
+// and stack trace are sent in response as well, it's meant for local
+// development only.
+func Recover(logger zerolog.Logger, m *metrics.Metrics, debugMode bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &responseWriter{ResponseWriter: w}
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if v == http.ErrAbortHandler {
+					// handler aborted response on purpose
+					panic(v)
+				}
+
+				stack := debug.Stack()
+				if m != nil {
+					m.HTTPPanics.Inc()
+				}
+				requestid.Logger(r.Context(), logger).Error().
+					Str("panic", fmt.Sprint(v)).
+					Str("stack", string(stack)).
+					Msg("Handler panicked")
+
+				if rw.status != 0 {
+					// part of response is sent already, connection is
+					// closed so client doesn't take it as complete one
+					panic(http.ErrAbortHandler)
+				}
+
+				p := problem.New(http.StatusInternalServerError, "")
+				if debugMode {
+					p.Detail = fmt.Sprint(v)
+					p.Stack = string(stack)
+				}
+				problem.Write(rw, r, p)
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bliuchak/heroes/internal/metrics"
+	"github.com/bliuchak/heroes/internal/server/problem"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecover(t *testing.T) {
+	tests := []struct {
+		name      string
+		debugMode bool
+		handler   http.HandlerFunc
+		code      int
+		panics    float64
+		// expected is part of response body
+		expected    string
+		notExpected string
+	}{
+		{
+			name:        "should answer with problem",
+			handler:     func(w http.ResponseWriter, r *http.Request) { panic("nil map") },
+			code:        http.StatusInternalServerError,
+			panics:      1,
+			expected:    `"request_id":"abc"`,
+			notExpected: "nil map",
+		},
+		{
+			name:      "should send stack in debug mode",
+			debugMode: true,
+			handler:   func(w http.ResponseWriter, r *http.Request) { panic("nil map") },
+			code:      http.StatusInternalServerError,
+			panics:    1,
+			expected:  `"request_id":"abc","stack":"goroutine`,
+		},
+		{
+			name:    "should pass response without panic",
+			handler: func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) },
+			code:    http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			md := Middleware{Logger: zerolog.New(&buf)}
+			m := metrics.New()
+
+			r := httptest.NewRequest(http.MethodGet, "/v1/hero/1", nil)
+			r.Header.Set(problem.RequestIDHeader, "abc")
+			rr := httptest.NewRecorder()
+			md.RequestID(Recover(md.Logger, m, tt.debugMode)(tt.handler)).ServeHTTP(rr, r)
+
+			assert.Equal(t, tt.code, rr.Code)
+			assert.Equal(t, tt.panics, testutil.ToFloat64(m.HTTPPanics))
+			if tt.panics == 0 {
+				assert.Empty(t, buf.String())
+				return
+			}
+
+			assert.Equal(t, problem.MediaType, rr.Header().Get("Content-Type"))
+			assert.Contains(t, rr.Body.String(), tt.expected)
+			if tt.debugMode {
+				assert.Contains(t, rr.Body.String(), `"detail":"nil map"`)
+			}
+			if tt.notExpected != "" {
+				assert.NotContains(t, rr.Body.String(), tt.notExpected)
+			}
+			assert.Contains(t, buf.String(), `"panic":"nil map"`)
+			assert.Contains(t, buf.String(), `"request_id":"abc"`)
+			assert.Contains(t, buf.String(), "recover_test.go")
+		})
+	}
+}
+
+func TestRecover_Aborts(t *testing.T) {
+	handlers := map[string]http.HandlerFunc{
+		"should abort started response": func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`[{"id":"1"`))
+			panic("nil map")
+		},
+		"should keep aborted response": func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		},
+	}
+	for name, h := range handlers {
+		t.Run(name, func(t *testing.T) {
+			assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+				Recover(zerolog.Nop(), nil, false)(h).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/heroes", nil))
+			})
+		})
+	}
+}
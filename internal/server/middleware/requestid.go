@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/bliuchak/heroes/internal/server/requestid"
+)
+
+// RequestID middleware accepts ID of request from X-Request-ID header or
+// generates new one, returns it in response header and stores it in request
+// context together with logger which adds it to every entry
+func (md *Middleware) RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id, md.Logger)))
+	})
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bliuchak/heroes/internal/server/requestid"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		expected string
+	}{
+		{name: "should accept ID of client", header: "abc-123", expected: "abc-123"},
+		{name: "should generate ID"},
+		{name: "should replace invalid ID", header: "abc\ndef"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			md := Middleware{Logger: zerolog.New(&buf)}
+
+			var id string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id = requestid.FromContext(r.Context())
+				requestid.Logger(r.Context(), zerolog.Nop()).Info().Msg("handled")
+			})
+
+			r := httptest.NewRequest(http.MethodGet, "/v1/hero/1", nil)
+			if tt.header != "" {
+				r.Header.Set(requestid.Header, tt.header)
+			}
+			rr := httptest.NewRecorder()
+			md.RequestID(next).ServeHTTP(rr, r)
+
+			assert.True(t, requestid.Valid(id))
+			if tt.expected != "" {
+				assert.Equal(t, tt.expected, id)
+			}
+			assert.Equal(t, id, rr.Header().Get(requestid.Header))
+			assert.Contains(t, buf.String(), `"request_id":"`+id+`"`)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/bliuchak/heroes/internal/tracing"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	otelattr "go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Trace middleware starts server span of request, trace context of caller
+// is taken from traceparent and tracestate headers. IDs of trace and span
+// are added to logger of request, so it has to run after RequestID.
+func Trace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, r.Method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				otelattr.String("http.request.method", r.Method),
+				otelattr.String("url.path", r.URL.Path),
+				otelattr.String("user_agent.original", r.UserAgent()),
+			),
+		)
+		defer span.End()
+
+		if sc := span.SpanContext(); sc.IsValid() {
+			l := zerolog.Ctx(ctx).With().
+				Str("trace_id", sc.TraceID().String()).
+				Str("span_id", sc.SpanID().String()).
+				Logger()
+			ctx = l.WithContext(ctx)
+		}
+
+		r, a := attributed(r.WithContext(ctx))
+		rw := &responseWriter{ResponseWriter: w}
+		next.ServeHTTP(rw, r)
+
+		status := rw.statusCode()
+		if route := a.routeOf(r); route != "" {
+			span.SetName(r.Method + " " + route)
+			span.SetAttributes(otelattr.String("http.route", route))
+		}
+		span.SetAttributes(otelattr.Int("http.response.status_code", status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	})
+}
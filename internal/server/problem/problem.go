@@ -0,0 +1,134 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/bliuchak/heroes/internal/apierror"
+	"github.com/bliuchak/heroes/internal/server/requestid"
+	"github.com/bliuchak/heroes/internal/storage"
+)
+
+// MediaType of problem details responses
+const MediaType = "application/problem+json"
+
+// RequestIDHeader is header which carries ID of request
+const RequestIDHeader = requestid.Header
+
+// problem types, about:blank means that problem has no additional
+// semantics beyond its status code
+const (
+	TypeBlank        = "about:blank"
+	TypeHeroNotFound = "/problems/hero-not-found"
+	TypeInvalid      = "/problems/invalid-request"
+)
+
+// Problem is problem details object (RFC 7807)
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+	// Stack is stack trace of panic, it's sent in debug mode only
+	Stack string `json:"stack,omitempty"`
+}
+
+// Error implements error interface, so problem can be returned from any layer
+func (p *Problem) Error() string {
+	if p.Detail == "" {
+		return p.Title
+	}
+	return p.Title + ": " + p.Detail
+}
+
+// New returns pointer to Problem with status code and detail
+func New(status int, detail string) *Problem {
+	return &Problem{
+		Type:   TypeBlank,
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Invalid returns pointer to Problem which describes invalid request
+func Invalid(detail string) *Problem {
+	return &Problem{
+		Type:   TypeInvalid,
+		Title:  "Request is not valid",
+		Status: http.StatusBadRequest,
+		Detail: detail,
+	}
+}
+
+// statusOf maps storage error kinds to HTTP status codes
+var statusOf = map[storage.Kind]int{
+	storage.ErrNotFound:           http.StatusNotFound,
+	storage.ErrConflict:           http.StatusConflict,
+	storage.ErrPreconditionFailed: http.StatusPreconditionFailed,
+	storage.ErrInvalid:            http.StatusBadRequest,
+	storage.ErrForbidden:          http.StatusForbidden,
+	storage.ErrUnavailable:        http.StatusServiceUnavailable,
+	storage.ErrInternal:           http.StatusInternalServerError,
+}
+
+// FromError maps error to Problem, this is the only place where storage
+// errors are translated to HTTP status codes. Unknown errors are reported as
+// internal error without details, so driver messages are not exposed.
+func FromError(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+	return FromAPIError(apierror.FromError(err))
+}
+
+// FromAPIError maps described storage error to Problem
+func FromAPIError(e apierror.Error) *Problem {
+	switch {
+	case e.Timeout:
+		return New(http.StatusGatewayTimeout, e.Message)
+	case e.Kind == storage.ErrNotFound:
+		return &Problem{
+			Type:   TypeHeroNotFound,
+			Title:  "Hero not found",
+			Status: http.StatusNotFound,
+			Detail: e.Message,
+		}
+	case e.Kind == storage.ErrInvalid:
+		return Invalid(e.Message)
+	case e.Kind == storage.ErrInternal:
+		return New(http.StatusInternalServerError, "")
+	default:
+		return New(statusOf[e.Kind], e.Message)
+	}
+}
+
+// Write writes problem as response to request r, instance and request ID
+// are taken from the request
+func Write(w http.ResponseWriter, r *http.Request, p *Problem) {
+	resp := *p
+	if resp.Instance == "" {
+		resp.Instance = r.URL.RequestURI()
+	}
+	if resp.RequestID == "" {
+		resp.RequestID = requestid.FromContext(r.Context())
+	}
+	if resp.RequestID == "" && requestid.Valid(r.Header.Get(RequestIDHeader)) {
+		resp.RequestID = r.Header.Get(RequestIDHeader)
+	}
+
+	data, _ := json.Marshal(resp)
+
+	w.Header().Set("Content-Type", MediaType)
+	w.WriteHeader(resp.Status)
+	w.Write(data)
+}
+
+// Error maps err to Problem and writes it as response to request r
+func Error(w http.ResponseWriter, r *http.Request, err error) {
+	Write(w, r, FromError(err))
+}
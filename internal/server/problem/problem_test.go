@@ -0,0 +1,103 @@
+package problem
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bliuchak/heroes/internal/server/requestid"
+	"github.com/bliuchak/heroes/internal/storage"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromError(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		typ    string
+		detail string
+	}{
+		{
+			name:   "should map missing hero",
+			err:    storage.NewError(storage.ErrNotFound, "GetHero", "1", nil),
+			status: http.StatusNotFound,
+			typ:    TypeHeroNotFound,
+			detail: "hero 1 doesn't exist",
+		},
+		{
+			name:   "should map conflict",
+			err:    storage.NewError(storage.ErrConflict, "UpdateHero", "1", errors.New("WRONGTYPE")),
+			status: http.StatusConflict,
+			typ:    TypeBlank,
+			detail: "hero 1 conflicts with stored data",
+		},
+		{
+			name:   "should map hero of other client",
+			err:    storage.NewError(storage.ErrForbidden, "DeleteHero", "1", nil),
+			status: http.StatusForbidden,
+			typ:    TypeBlank,
+			detail: "hero 1 is owned by other client",
+		},
+		{
+			name:   "should map unavailable storage",
+			err:    storage.NewError(storage.ErrUnavailable, "GetHeroes", "", errors.New("connection refused")),
+			status: http.StatusServiceUnavailable,
+			typ:    TypeBlank,
+			detail: "storage is unavailable",
+		},
+		{
+			name:   "should keep problem",
+			err:    Invalid("bad id"),
+			status: http.StatusBadRequest,
+			typ:    TypeInvalid,
+			detail: "bad id",
+		},
+		{
+			name:   "should hide unknown error",
+			err:    errors.New("dial tcp: connection refused"),
+			status: http.StatusInternalServerError,
+			typ:    TypeBlank,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := FromError(tt.err)
+			assert.Equal(t, tt.status, p.Status)
+			assert.Equal(t, tt.typ, p.Type)
+			assert.Equal(t, tt.detail, p.Detail)
+		})
+	}
+}
+
+func TestWrite(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/v1/hero/1?x=1", nil)
+	r.Header.Set(RequestIDHeader, "abc")
+	rr := httptest.NewRecorder()
+
+	Error(rr, r, storage.NewError(storage.ErrNotFound, "GetHero", "1", nil))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, MediaType, rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "/problems/hero-not-found",
+		"title": "Hero not found",
+		"status": 404,
+		"detail": "hero 1 doesn't exist",
+		"instance": "/v1/hero/1?x=1",
+		"request_id": "abc"
+	}`, rr.Body.String())
+}
+
+func TestWrite_RequestIDOfContext(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/v1/hero/1", nil)
+	r.Header.Set(RequestIDHeader, "abc")
+	r = r.WithContext(requestid.NewContext(r.Context(), "def", zerolog.Nop()))
+	rr := httptest.NewRecorder()
+
+	Error(rr, r, storage.NewError(storage.ErrNotFound, "GetHero", "1", nil))
+
+	assert.Contains(t, rr.Body.String(), `"request_id":"def"`)
+}
@@ -0,0 +1,66 @@
+// Package requestid carries ID of request and logger which is tied to it
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/rs/zerolog"
+)
+
+// Header is header which carries ID of request
+const Header = "X-Request-ID"
+
+// maxLen limits length of ID accepted from client
+const maxLen = 128
+
+// New returns random ID
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand doesn't fail on supported platforms
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// Valid reports whether ID sent by client may be used, only IDs made of
+// letters, digits and -_.: are accepted, so they are safe to log and echo
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+type idKey struct{}
+
+// NewContext returns copy of ctx which carries ID and logger which adds
+// ID to every entry
+func NewContext(ctx context.Context, id string, logger zerolog.Logger) context.Context {
+	l := logger.With().Str("request_id", id).Logger()
+	return l.WithContext(context.WithValue(ctx, idKey{}, id))
+}
+
+// FromContext returns ID stored in ctx
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(idKey{}).(string)
+	return id
+}
+
+// Logger returns logger stored in ctx, or fallback when ctx has none
+func Logger(ctx context.Context, fallback zerolog.Logger) *zerolog.Logger {
+	if FromContext(ctx) == "" {
+		return &fallback
+	}
+	return zerolog.Ctx(ctx)
+}
@@ -0,0 +1,49 @@
+package requestid
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{id: "4f9a-2b_c.d:1", valid: true},
+		{id: New(), valid: true},
+		{id: strings.Repeat("a", maxLen), valid: true},
+		{id: ""},
+		{id: strings.Repeat("a", maxLen+1)},
+		{id: "abc def"},
+		{id: "abc\nfake log entry"},
+		{id: "<script>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			assert.Equal(t, tt.valid, Valid(tt.id))
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	assert.Len(t, New(), 32)
+	assert.NotEqual(t, New(), New())
+}
+
+func TestLogger(t *testing.T) {
+	var fallback, scoped bytes.Buffer
+
+	Logger(context.Background(), zerolog.New(&fallback)).Info().Msg("no request")
+	assert.NotContains(t, fallback.String(), "request_id")
+
+	ctx := NewContext(context.Background(), "abc", zerolog.New(&scoped))
+	assert.Equal(t, "abc", FromContext(ctx))
+	Logger(ctx, zerolog.New(&fallback)).Info().Msg("request")
+	assert.Contains(t, scoped.String(), `"request_id":"abc"`)
+}
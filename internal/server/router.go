@@ -3,22 +3,134 @@ package server
 import (
 	"net/http"
 
+	"github.com/bliuchak/heroes/api"
+	"github.com/bliuchak/heroes/internal/auth"
+	"github.com/bliuchak/heroes/internal/health"
+	"github.com/bliuchak/heroes/internal/ratelimit"
+	"github.com/bliuchak/heroes/internal/server/graphql"
 	"github.com/bliuchak/heroes/internal/server/handlers"
 	"github.com/bliuchak/heroes/internal/server/middleware"
+	"github.com/bliuchak/heroes/internal/storage"
+	"github.com/gorilla/mux"
 )
 
 // SetRoutes setter for basic routes
 func (s *Server) SetRoutes() {
-	statusHandler := handlers.StatusHandler{}
+	statusHandler := handlers.StatusHandler{Ready: s.Ready}
+	statusHandler.SetLogger(s.Logger)
 	statusHandler.SetStorage(s.Storage)
 
+	healthHandler := handlers.HealthHandler{Readiness: s.Readiness, Monitor: s.Health}
+	healthHandler.SetLogger(s.Logger)
+	if healthHandler.Monitor == nil {
+		healthHandler.Monitor = health.NewMonitor(s.Config.Server.ReadinessTimeout, health.StorageDependency(s.Storage))
+	}
+
+	openAPIHandler := handlers.OpenAPIHandler{Spec: api.OpenAPI}
+
+	s.Router.HandleFunc("/status", statusHandler.GetStatusHandler).Methods(http.MethodGet)
+	s.Router.HandleFunc("/healthz", healthHandler.GetLivenessHandler).Methods(http.MethodGet)
+	s.Router.HandleFunc("/readyz", healthHandler.GetReadinessHandler).Methods(http.MethodGet)
+	limitIP, authenticate, rateLimit := s.limitIP(), s.authenticate(), s.rateLimit()
+	s.Router.Handle("/graphql", limitIP(authenticate(rateLimit(scoped(auth.ScopeRead, graphql.NewHandler(s.Storage, s.Logger).ServeHTTP))))).Methods(http.MethodPost)
+	s.Router.HandleFunc("/openapi.json", openAPIHandler.GetOpenAPIHandler).Methods(http.MethodGet)
+	if s.Metrics != nil {
+		s.Router.Handle("/metrics", s.Metrics.Handler()).Methods(http.MethodGet)
+	}
+
+	v1 := s.Router.PathPrefix("/v1").Subrouter()
+	s.SetV1Routes(v1)
+	if keys := s.keys(); keys != nil {
+		s.SetAdminRoutes(v1.PathPrefix("/admin").Subrouter(), keys)
+	}
+
+	// unversioned routes are kept as deprecated aliases of v1
+	legacy := s.Router.NewRoute().Subrouter()
+	legacy.Use(middleware.Deprecated(s.Config.Server.LegacyDeprecatedAt, s.Config.Server.LegacySunset, "/v1"))
+	s.SetV1Routes(legacy)
+}
+
+// SetV1Routes setter for v1 hero routes
+// every API version gets own hero handler, so next version can use different
+// hero representation while sharing the same storage
+func (s *Server) SetV1Routes(r *mux.Router) {
 	heroHandler := handlers.HeroHandler{}
 	heroHandler.SetLogger(s.Logger)
 	heroHandler.SetStorage(s.Storage)
 
-	s.Router.HandleFunc("/status", statusHandler.GetStatusHandler).Methods(http.MethodGet)
-	s.Router.HandleFunc("/heroes", heroHandler.GetHeroesHandler).Methods(http.MethodGet)
-	s.Router.HandleFunc("/hero/{id:[0-9]+}", heroHandler.GetHeroHandler).Methods(http.MethodGet)
-	s.Router.HandleFunc("/hero", middleware.IsJSONValid(heroHandler.CreateHeroHandler)).Methods(http.MethodPost)
-	s.Router.HandleFunc("/hero/{id:[0-9]+}", heroHandler.DeleteHeroHandler).Methods(http.MethodDelete)
+	r.Use(middleware.Negotiate, s.limitIP(), s.authenticate(), s.rateLimit())
+	r.Handle("/heroes", scoped(auth.ScopeRead, heroHandler.GetHeroesHandler)).Methods(http.MethodGet)
+	r.Handle("/me/heroes", scoped(auth.ScopeRead, heroHandler.GetMyHeroesHandler)).Methods(http.MethodGet)
+	r.Handle("/hero/{id:[0-9]+}", scoped(auth.ScopeRead, heroHandler.GetHeroHandler)).Methods(http.MethodGet)
+	r.Handle("/hero", scoped(auth.ScopeWrite, heroHandler.CreateHeroHandler)).Methods(http.MethodPost)
+	r.Handle("/hero/{id:[0-9]+}", scoped(auth.ScopeWrite, heroHandler.UpdateHeroHandler)).Methods(http.MethodPut)
+	r.Handle("/hero/{id:[0-9]+}", scoped(auth.ScopeWrite, heroHandler.DeleteHeroHandler)).Methods(http.MethodDelete)
+}
+
+// SetAdminRoutes setter for API keys management routes, r must be
+// authenticated by its parent router
+func (s *Server) SetAdminRoutes(r *mux.Router, keys storage.KeyStorager) {
+	keyHandler := handlers.APIKeyHandler{Keys: keys}
+	keyHandler.SetLogger(s.Logger)
+
+	r.Use(middleware.RequireScope(auth.ScopeAdmin))
+	r.HandleFunc("/keys", keyHandler.GetAPIKeysHandler).Methods(http.MethodGet)
+	r.HandleFunc("/keys", keyHandler.CreateAPIKeyHandler).Methods(http.MethodPost)
+	r.HandleFunc("/keys/{id:[0-9a-f]+}", keyHandler.DeleteAPIKeyHandler).Methods(http.MethodDelete)
+}
+
+// authenticate returns authentication middleware, every request is let in
+// when auth is disabled
+func (s *Server) authenticate() mux.MiddlewareFunc {
+	if !s.Config.Auth.Enabled {
+		return middleware.Anonymous
+	}
+	return middleware.Authenticate(s.Logger, middleware.Authenticators(s.keys(), s.Config.Auth.AdminKey, s.JWT)...)
+}
+
+// rateLimit returns rate limiting middleware, limits are applied after
+// authentication, so clients are told apart by their credentials
+func (s *Server) rateLimit() mux.MiddlewareFunc {
+	rules, ok := s.rateLimitRules()
+	if !ok {
+		return passThrough
+	}
+	return s.middleware().RateLimit(s.Limiter, rules)
+}
+
+// limitIP returns middleware which limits requests by IP before they are
+// authenticated, it's needed only when auth is enabled, otherwise rateLimit
+// already tells clients apart by IP
+func (s *Server) limitIP() mux.MiddlewareFunc {
+	if !s.Config.Auth.Enabled || s.Config.RateLimit.IP.IsZero() || s.Limiter == nil {
+		return passThrough
+	}
+	return s.middleware().RateLimitIP(s.Limiter, s.Config.RateLimit.IP)
+}
+
+func (s *Server) rateLimitRules() (ratelimit.Rules, bool) {
+	rules := ratelimit.Rules{
+		Default: s.Config.RateLimit.Default,
+		Routes:  s.Config.RateLimit.Routes,
+		Clients: s.Config.RateLimit.Clients,
+	}
+	return rules, !rules.IsZero() && s.Limiter != nil
+}
+
+func passThrough(next http.Handler) http.Handler {
+	return next
+}
+
+// keys returns storage of API keys
+func (s *Server) keys() storage.KeyStorager {
+	if s.Keys != nil {
+		return s.Keys
+	}
+	ks, _ := s.Storage.(storage.KeyStorager)
+	return ks
+}
+
+// scoped wraps handler with check of principal scope
+func scoped(scope string, h http.HandlerFunc) http.Handler {
+	return middleware.RequireScope(scope)(h)
 }
@@ -0,0 +1,367 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bliuchak/heroes/api"
+	"github.com/bliuchak/heroes/internal/auth"
+	"github.com/bliuchak/heroes/internal/config"
+	"github.com/bliuchak/heroes/internal/ratelimit"
+	"github.com/bliuchak/heroes/internal/server/middleware"
+	"github.com/bliuchak/heroes/internal/storage"
+	stmocks "github.com/bliuchak/heroes/internal/storage/mocks"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// muxVar matches route variable with pattern, e.g. {id:[0-9]+}
+var muxVar = regexp.MustCompile(`\{([^:}]+):[^}]+\}`)
+
+func TestRouter_RoutesAreDocumented(t *testing.T) {
+	doc, err := openapi3.NewLoader().LoadFromData(api.OpenAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewServer(new(stmocks.Storager), zerolog.Nop(), config.Config{})
+	s.Keys = new(stmocks.KeyStorager)
+	s.InitRouter()
+	s.SetRoutes()
+
+	routes := map[string]bool{}
+	err = s.Router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			// subrouter without own path
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			// subrouter or path prefix
+			return nil
+		}
+
+		path := muxVar.ReplaceAllString(tpl, "{$1}")
+		for _, m := range methods {
+			routes[m+" "+path] = true
+
+			item := doc.Paths.Find(path)
+			if item == nil || item.GetOperation(m) == nil {
+				t.Errorf("route %s %s is not described in OpenAPI document", m, path)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, item := range doc.Paths.Map() {
+		for m := range item.Operations() {
+			if !routes[strings.ToUpper(m)+" "+path] {
+				t.Errorf("operation %s %s from OpenAPI document has no route", m, path)
+			}
+		}
+	}
+}
+
+func TestRouter_LegacyRoutesAreDeprecated(t *testing.T) {
+	st := new(stmocks.Storager)
+	st.On("GetHero", mock.Anything, "1").Return(storage.Hero{ID: "1", Name: "Batman"}, nil)
+
+	s := NewServer(st, zerolog.Nop(), config.Config{})
+	s.InitRouter()
+	s.SetRoutes()
+
+	rr := httptest.NewRecorder()
+	s.Router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/hero/1", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get("Deprecation"))
+
+	rr = httptest.NewRecorder()
+	s.Router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/hero/1", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotEmpty(t, rr.Header().Get("Deprecation"))
+	assert.NotEmpty(t, rr.Header().Get("Sunset"))
+	assert.Equal(t, `</v1/hero/1>; rel="successor-version"`, rr.Header().Get("Link"))
+}
+
+func TestRouter_ScopesAreChecked(t *testing.T) {
+	key, token, err := auth.NewAPIKey("reader", []string{auth.ScopeRead})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := new(stmocks.KeyStorager)
+	keys.On("GetAPIKey", mock.Anything, key.ID).Return(key, nil)
+	st := new(stmocks.Storager)
+	st.On("GetHero", mock.Anything, "1").Return(storage.Hero{ID: "1", Name: "Batman"}, nil)
+
+	s := NewServer(st, zerolog.Nop(), config.Config{Auth: config.Auth{Enabled: true, AdminKey: "root"}})
+	s.Keys = keys
+	s.InitRouter()
+	s.SetRoutes()
+	s.SetMiddleware()
+
+	tests := []struct {
+		method string
+		path   string
+		key    string
+		code   int
+	}{
+		{http.MethodGet, "/v1/hero/1", "", http.StatusUnauthorized},
+		{http.MethodGet, "/v1/hero/1", "abc.def", http.StatusUnauthorized},
+		{http.MethodGet, "/v1/hero/1", token, http.StatusOK},
+		{http.MethodGet, "/hero/1", token, http.StatusOK},
+		{http.MethodDelete, "/v1/hero/1", token, http.StatusForbidden},
+		{http.MethodDelete, "/hero/1", token, http.StatusForbidden},
+		{http.MethodGet, "/v1/admin/keys", token, http.StatusForbidden},
+		{http.MethodGet, "/healthz", "", http.StatusOK},
+	}
+	keys.On("GetAPIKey", mock.Anything, "abc").Return(storage.APIKey{}, storage.NewError(storage.ErrNotFound, "GetAPIKey", "abc", nil))
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.key != "" {
+				r.Header.Set(middleware.APIKeyHeader, tt.key)
+			}
+			rr := httptest.NewRecorder()
+			s.Router.ServeHTTP(rr, r)
+			assert.Equal(t, tt.code, rr.Code)
+		})
+	}
+}
+
+func TestRouter_RateLimited(t *testing.T) {
+	st := new(stmocks.Storager)
+	st.On("GetHeroes", mock.Anything).Return([]storage.Hero{{ID: "1", Name: "Batman"}}, nil)
+	st.On("GetHero", mock.Anything, "1").Return(storage.Hero{ID: "1", Name: "Batman"}, nil)
+
+	s := NewServer(st, zerolog.Nop(), config.Config{RateLimit: config.RateLimit{
+		Routes: map[string]ratelimit.Limit{"GET /v1/heroes": {Requests: 1, Window: time.Minute}},
+	}})
+	s.InitRouter()
+	s.SetRoutes()
+	s.SetMiddleware()
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		s.Router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		return rr
+	}
+
+	assert.Equal(t, http.StatusOK, get("/v1/heroes").Code)
+	rr := get("/v1/heroes")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.NotEmpty(t, rr.Header().Get("Retry-After"))
+
+	// deprecated alias shares bucket with its v1 route
+	assert.Equal(t, http.StatusTooManyRequests, get("/heroes").Code)
+
+	// routes without limit are not limited
+	for i := 0; i < 3; i++ {
+		rr = get("/v1/hero/1")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, rr.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func TestRouter_InvalidKeysAreRateLimited(t *testing.T) {
+	keys := new(stmocks.KeyStorager)
+	keys.On("GetAPIKey", mock.Anything, "abc").Return(storage.APIKey{}, storage.NewError(storage.ErrNotFound, "GetAPIKey", "abc", nil)).Twice()
+
+	s := NewServer(new(stmocks.Storager), zerolog.Nop(), config.Config{
+		Auth:      config.Auth{Enabled: true},
+		RateLimit: config.RateLimit{IP: ratelimit.Limit{Requests: 2, Window: time.Minute}},
+	})
+	s.Keys = keys
+	s.InitRouter()
+	s.SetRoutes()
+	s.SetMiddleware()
+
+	get := func() *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/v1/hero/1", nil)
+		r.Header.Set(middleware.APIKeyHeader, "abc.def")
+		rr := httptest.NewRecorder()
+		s.Router.ServeHTTP(rr, r)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, get().Code)
+	assert.Equal(t, http.StatusUnauthorized, get().Code)
+	// the third request is limited by IP before its key is looked up
+	assert.Equal(t, http.StatusTooManyRequests, get().Code)
+	keys.AssertExpectations(t)
+}
+
+func TestRouter_ClientLimitAboveDefaultIsKept(t *testing.T) {
+	st := new(stmocks.Storager)
+	st.On("GetHero", mock.Anything, "1").Return(storage.Hero{ID: "1", Name: "Batman"}, nil)
+
+	s := NewServer(st, zerolog.Nop(), config.Config{
+		Auth: config.Auth{Enabled: true, AdminKey: "root"},
+		RateLimit: config.RateLimit{
+			Default: ratelimit.Limit{Requests: 2, Window: time.Minute},
+			Clients: map[string]ratelimit.Limit{"admin": {Requests: 100, Window: time.Minute}},
+			IP:      ratelimit.Limit{Requests: 50, Window: time.Minute},
+		},
+	})
+	s.InitRouter()
+	s.SetRoutes()
+	s.SetMiddleware()
+
+	for i := 0; i < 5; i++ {
+		r := httptest.NewRequest(http.MethodGet, "/v1/hero/1", nil)
+		r.Header.Set(middleware.APIKeyHeader, "root")
+		rr := httptest.NewRecorder()
+		s.Router.ServeHTTP(rr, r)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "100", rr.Header().Get("RateLimit-Limit"))
+	}
+}
+
+func TestServer_HandlerAnswersPreflight(t *testing.T) {
+	s := NewServer(new(stmocks.Storager), zerolog.Nop(), config.Config{
+		Auth: config.Auth{Enabled: true},
+		CORS: config.CORS{
+			Origins: []string{"https://*.example.com"},
+			Methods: []string{http.MethodGet, http.MethodPut, http.MethodDelete},
+			Headers: []string{"X-API-Key"},
+		},
+	})
+	s.Keys = new(stmocks.KeyStorager)
+	s.InitRouter()
+	s.SetRoutes()
+	s.SetMiddleware()
+
+	for _, path := range []string{"/v1/hero/1", "/v1/heroes", "/hero/1", "/graphql", "/v1/admin/keys"} {
+		t.Run(path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodOptions, path, nil)
+			r.Header.Set("Origin", "https://app.example.com")
+			r.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+			r.Header.Set("Access-Control-Request-Headers", "X-API-Key")
+			rr := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rr, r)
+
+			assert.Equal(t, http.StatusNoContent, rr.Code)
+			assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, "GET, PUT, DELETE", rr.Header().Get("Access-Control-Allow-Methods"))
+		})
+	}
+
+	// actual request is authenticated as usual, CORS headers let browser read the error
+	r := httptest.NewRequest(http.MethodGet, "/v1/hero/1", nil)
+	r.Header.Set("Origin", "https://app.example.com")
+	rr := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rr, r)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+	assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Contains(t, rr.Header().Get("Access-Control-Expose-Headers"), "WWW-Authenticate")
+}
+
+func TestServer_HandlerTagsRequestID(t *testing.T) {
+	st := new(stmocks.Storager)
+	st.On("GetHero", mock.Anything, "1").Return(storage.Hero{}, storage.NewError(storage.ErrNotFound, "GetHero", "1", nil))
+
+	s := NewServer(st, zerolog.Nop(), config.Config{})
+	s.InitRouter()
+	s.SetRoutes()
+	s.SetMiddleware()
+
+	for _, path := range []string{"/v1/hero/1", "/v1/unknown"} {
+		t.Run(path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+
+			id := rr.Header().Get("X-Request-ID")
+			assert.Len(t, id, 32)
+			if rr.Header().Get("Content-Type") == "application/problem+json" {
+				assert.Contains(t, rr.Body.String(), `"request_id":"`+id+`"`)
+			}
+		})
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/v1/hero/1", nil)
+	r.Header.Set("X-Request-ID", "abc-123")
+	rr := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rr, r)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "abc-123", rr.Header().Get("X-Request-ID"))
+	assert.Contains(t, rr.Body.String(), `"request_id":"abc-123"`)
+}
+
+func TestServer_HandlerLogsAccess(t *testing.T) {
+	st := new(stmocks.Storager)
+	st.On("GetHero", mock.Anything, "1").Return(storage.Hero{ID: "1", Name: "Batman"}, nil)
+
+	var buf strings.Builder
+	s := NewServer(st, zerolog.New(&buf), config.Config{})
+	s.InitRouter()
+	s.SetRoutes()
+	s.SetMiddleware()
+
+	for _, path := range []string{"/v1/hero/1", "/v1/unknown"} {
+		s.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.Contains(t, lines[0], `"route":"/v1/hero/{id}"`)
+		assert.Contains(t, lines[0], `"status":200`)
+		assert.Contains(t, lines[0], `"request_id":`)
+		assert.Contains(t, lines[1], `"status":404`)
+	}
+}
+
+func TestServer_HandlerServesMetrics(t *testing.T) {
+	st := new(stmocks.Storager)
+	st.On("GetHero", mock.Anything, "1").Return(storage.Hero{ID: "1", Name: "Batman"}, nil)
+
+	s := NewServer(st, zerolog.Nop(), config.Config{})
+	s.InitRouter()
+	s.SetRoutes()
+	s.SetMiddleware()
+
+	s.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/hero/1", nil))
+
+	rr := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `heroes_http_requests_total{method="GET",route="/v1/hero/{id}",status="200"} 1`)
+	assert.Contains(t, rr.Body.String(), "heroes_http_requests_in_flight 1")
+}
+
+func TestServer_HandlerRecoversPanic(t *testing.T) {
+	st := new(stmocks.Storager)
+	st.On("GetHero", mock.Anything, "1").Run(func(mock.Arguments) { panic("nil map") })
+
+	var buf strings.Builder
+	s := NewServer(st, zerolog.New(&buf), config.Config{})
+	s.InitRouter()
+	s.SetRoutes()
+	s.SetMiddleware()
+
+	rr := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/v1/hero/1", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"request_id":"`+rr.Header().Get("X-Request-ID")+`"`)
+	assert.NotContains(t, rr.Body.String(), "stack")
+	assert.Contains(t, buf.String(), "Handler panicked")
+	// recovered request is logged and measured as any other one
+	assert.Contains(t, buf.String(), `"status":500`)
+
+	rr = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rr.Body.String(), "heroes_http_panics_total 1")
+}
@@ -1,12 +1,21 @@
 package server
 
 import (
+	"context"
+	"net"
 	"net/http"
 	"strconv"
+	"sync/atomic"
 	"time"
 
+	"github.com/bliuchak/heroes/api"
+	"github.com/bliuchak/heroes/internal/auth"
 	"github.com/bliuchak/heroes/internal/config"
+	"github.com/bliuchak/heroes/internal/health"
+	"github.com/bliuchak/heroes/internal/metrics"
+	"github.com/bliuchak/heroes/internal/ratelimit"
 	"github.com/bliuchak/heroes/internal/server/middleware"
+	"github.com/bliuchak/heroes/internal/server/problem"
 	"github.com/bliuchak/heroes/internal/storage"
 	"github.com/gorilla/mux"
 	"github.com/rs/zerolog"
@@ -17,6 +26,10 @@ type Serverer interface {
 	InitRouter()
 	SetMiddleware()
 	Run() error
+	SetReady(ready bool)
+	SetMaintenance(on bool)
+	Shutdown(ctx context.Context) error
+	Close() error
 }
 
 // Server app container for main dependencies
@@ -25,6 +38,25 @@ type Server struct {
 	Storage storage.Storager
 	Logger  zerolog.Logger
 	Config  config.Config
+
+	// Keys keeps API keys, if un-set Storage is used when it's able to
+	Keys storage.KeyStorager
+	// JWT verifies bearer tokens, if un-set only API keys are accepted
+	JWT *auth.JWTVerifier
+	// Health checks dependencies for readiness probe, if un-set only
+	// storage is checked
+	Health *health.Monitor
+	// Limiter keeps rate limits of clients, it's local by default
+	Limiter ratelimit.Limiter
+	// Metrics collects metrics of requests served at /metrics
+	Metrics *metrics.Metrics
+
+	srv *http.Server
+	// ready is reported by readiness probe, it's unset on shutdown so load
+	// balancer stops sending new requests before connections are closed
+	ready int32
+	// maintenance fails readiness while server keeps serving requests
+	maintenance int32
 }
 
 // NewServer create pointer for new server structure
@@ -33,6 +65,13 @@ func NewServer(storage storage.Storager, logger zerolog.Logger, config config.Co
 		Storage: storage,
 		Logger:  logger,
 		Config:  config,
+		Limiter: ratelimit.NewLocal(),
+		Metrics: metrics.New(),
+		srv: &http.Server{
+			Addr:         ":" + strconv.Itoa(config.Server.Port),
+			WriteTimeout: 1 * time.Second,
+			ReadTimeout:  1 * time.Second,
+		},
 	}
 }
 
@@ -43,29 +82,129 @@ func (s *Server) InitRouter() {
 
 // SetMiddleware middleware setter
 func (s *Server) SetMiddleware() {
-	md := middleware.Middleware{Logger: s.Logger}
-	md.SetLogger(s.Logger)
+	s.Router.Use(middleware.RecordRoute)
+	s.Router.Use(middleware.MustRequestValidator(api.OpenAPI))
+}
+
+// exposedHeaders are response headers readable by scripts of other origins
+var exposedHeaders = []string{
+	"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After",
+	"Deprecation", "Sunset", "Link", "WWW-Authenticate", problem.RequestIDHeader,
+}
 
-	s.Router.Use(md.HTTPLogger)
+// middleware returns middleware configured for this server
+func (s *Server) middleware() *middleware.Middleware {
+	return &middleware.Middleware{
+		Logger:         s.Logger,
+		Sample:         s.Config.AccessLog.Sample,
+		TrustedProxies: s.Config.AccessLog.TrustedProxies,
+	}
 }
 
-// Run runs http server
+// Handler returns router wrapped with middleware which has to run before
+// routing: request ID is assigned, request is traced, logged and measured
+// including unmatched ones, CORS preflight doesn't match any route. Panics
+// are recovered innermost, so recovered request is logged and measured.
+func (s *Server) Handler() http.Handler {
+	md := s.middleware()
+
+	var h http.Handler = s.Router
+	if conf := s.Config.CORS; len(conf.Origins) > 0 {
+		h = middleware.CORS(middleware.CORSOptions{
+			AllowedOrigins:   conf.Origins,
+			AllowedMethods:   conf.Methods,
+			AllowedHeaders:   conf.Headers,
+			ExposedHeaders:   exposedHeaders,
+			AllowCredentials: conf.Credentials,
+			MaxAge:           conf.MaxAge,
+		})(h)
+	}
+	h = middleware.Recover(s.Logger, s.Metrics, s.Config.Server.Debug)(h)
+	h = md.HTTPLogger(h)
+	if s.Metrics != nil {
+		h = middleware.Metrics(s.Metrics)(h)
+	}
+	return md.RequestID(middleware.Trace(h))
+}
+
+// Run runs http server on configured port
 func (s *Server) Run() error {
+	lis, err := net.Listen("tcp", s.srv.Addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(lis)
+}
+
+// Serve accepts connections on given listener
+func (s *Server) Serve(lis net.Listener) error {
 	s.InitRouter()
 	s.SetRoutes()
 
 	s.SetMiddleware()
 
-	server := &http.Server{
-		Handler:      s.Router,
-		Addr:         ":" + strconv.Itoa(s.Config.Server.Port),
-		WriteTimeout: 1 * time.Second,
-		ReadTimeout:  1 * time.Second,
-	}
+	s.srv.Handler = s.Handler()
+	s.SetReady(true)
 
-	err := server.ListenAndServe()
-	if err != nil {
+	err := s.srv.Serve(lis)
+	if err != nil && err != http.ErrServerClosed {
 		return err
 	}
 	return nil
 }
+
+// SetReady sets whether server is ready to accept new requests
+func (s *Server) SetReady(ready bool) {
+	var v int32
+	if ready {
+		v = 1
+	}
+	atomic.StoreInt32(&s.ready, v)
+}
+
+// SetMaintenance turns maintenance mode on or off, server in maintenance
+// mode isn't ready but keeps serving requests which reach it
+func (s *Server) SetMaintenance(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&s.maintenance, v)
+}
+
+// Ready reports whether server is ready to accept new requests
+func (s *Server) Ready() bool {
+	ready, _ := s.Readiness()
+	return ready
+}
+
+// Readiness reports whether server is ready to accept new requests
+// and the reason when it isn't
+func (s *Server) Readiness() (bool, string) {
+	if atomic.LoadInt32(&s.ready) != 1 {
+		return false, "server is shutting down"
+	}
+	if atomic.LoadInt32(&s.maintenance) == 1 {
+		return false, "server is in maintenance mode"
+	}
+	return true, ""
+}
+
+// Shutdown stops accepting new connections and waits until in-flight
+// requests are finished, connections which are still active when ctx is
+// done are closed
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.SetReady(false)
+
+	err := s.srv.Shutdown(ctx)
+	if err != nil {
+		s.srv.Close()
+	}
+	return err
+}
+
+// Close immediately closes all listeners and connections
+func (s *Server) Close() error {
+	return s.srv.Close()
+}
@@ -0,0 +1,118 @@
+package server
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/config"
+	"github.com/bliuchak/heroes/internal/storage"
+	stmocks "github.com/bliuchak/heroes/internal/storage/mocks"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// serveSlowHero runs server which answers GET /v1/hero/1 only after release
+// is closed, started is closed when request reaches storage
+func serveSlowHero(t *testing.T, release <-chan struct{}) (s *Server, addr string, started <-chan struct{}) {
+	entered := make(chan struct{})
+	st := new(stmocks.Storager)
+	st.On("GetHero", mock.Anything, "1").Return(storage.Hero{ID: "1", Name: "Batman"}, nil).
+		Run(func(mock.Arguments) {
+			close(entered)
+			<-release
+		})
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s = NewServer(st, zerolog.Nop(), config.Config{})
+	go s.Serve(lis)
+
+	assert.Eventually(t, s.Ready, time.Second, time.Millisecond)
+	return s, lis.Addr().String(), entered
+}
+
+// refused reports whether server stopped accepting connections
+func refused(addr string) func() bool {
+	return func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			return true
+		}
+		conn.Close()
+		return false
+	}
+}
+
+func TestServer_ShutdownDrainsRequests(t *testing.T) {
+	release := make(chan struct{})
+	s, addr, started := serveSlowHero(t, release)
+
+	codes := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/v1/hero/1")
+		if err != nil {
+			codes <- 0
+			return
+		}
+		resp.Body.Close()
+		codes <- resp.StatusCode
+	}()
+
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- s.Shutdown(context.Background()) }()
+
+	// listener is closed once shutdown started draining
+	assert.Eventually(t, refused(addr), time.Second, time.Millisecond)
+	assert.False(t, s.Ready())
+	select {
+	case <-shutdown:
+		t.Fatal("shutdown finished before in-flight request")
+	default:
+	}
+
+	close(release)
+	assert.Equal(t, http.StatusOK, <-codes)
+	assert.NoError(t, <-shutdown)
+}
+
+func TestServer_ShutdownGraceExceeded(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	s, addr, started := serveSlowHero(t, release)
+
+	go http.Get("http://" + addr + "/v1/hero/1")
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, context.DeadlineExceeded, s.Shutdown(ctx))
+}
+
+func TestServer_Readiness(t *testing.T) {
+	s := NewServer(new(stmocks.Storager), zerolog.Nop(), config.Config{})
+
+	ready, reason := s.Readiness()
+	assert.False(t, ready)
+	assert.Equal(t, "server is shutting down", reason)
+
+	s.SetReady(true)
+	assert.True(t, s.Ready())
+
+	s.SetMaintenance(true)
+	ready, reason = s.Readiness()
+	assert.False(t, ready)
+	assert.Equal(t, "server is in maintenance mode", reason)
+
+	s.SetMaintenance(false)
+	assert.True(t, s.Ready())
+}
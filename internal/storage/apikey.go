@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// KeyStorager is implemented by storages which keep API keys
+type KeyStorager interface {
+	CreateAPIKey(ctx context.Context, key APIKey) error
+	GetAPIKey(ctx context.Context, id string) (APIKey, error)
+	GetAPIKeys(ctx context.Context) ([]APIKey, error)
+	// DeleteAPIKey revokes key, returns ErrNotFound when key doesn't exist
+	DeleteAPIKey(ctx context.Context, id string) error
+}
+
+// APIKey contains API key data, secret part of key is never stored,
+// only its hash
+type APIKey struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Hash      string    `json:"-"`
+	Scopes    []string  `json:"scopes"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,251 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/storage"
+)
+
+// Cache is a read-through storage.Storager decorator
+// it keeps size-bounded LRU of heroes returned by GetHero and optionally
+// short-lived copy of GetHeroes result
+type Cache struct {
+	next    storage.Storager
+	size    int
+	ttl     time.Duration
+	listTTL time.Duration
+	now     func() time.Time
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+	// gen is increased on every invalidation, results fetched while
+	// invalidation happened are not stored
+	gen uint64
+
+	heroes        []storage.Hero
+	heroesExpires time.Time
+
+	hits   uint64
+	misses uint64
+}
+
+type entry struct {
+	id      string
+	hero    storage.Hero
+	expires time.Time
+}
+
+// New returns pointer to Cache which wraps next storage
+// size limits number of cached heroes, ttl limits how long single hero
+// is cached and listTTL how long list of heroes is cached (0 disables it)
+func New(next storage.Storager, size int, ttl, listTTL time.Duration) *Cache {
+	return &Cache{
+		next:    next,
+		size:    size,
+		ttl:     ttl,
+		listTTL: listTTL,
+		now:     time.Now,
+		ll:      list.New(),
+		items:   make(map[string]*list.Element),
+	}
+}
+
+// Status checks storage connection status
+func (c *Cache) Status(ctx context.Context) (string, error) {
+	return c.next.Status(ctx)
+}
+
+// GetHeroes gets all heroes from cache or from underlying storage
+func (c *Cache) GetHeroes(ctx context.Context) ([]storage.Hero, error) {
+	if c.listTTL <= 0 {
+		return c.next.GetHeroes(ctx)
+	}
+
+	c.mu.Lock()
+	if c.heroes != nil && c.now().Before(c.heroesExpires) {
+		hs := copyHeroes(c.heroes)
+		c.mu.Unlock()
+		atomic.AddUint64(&c.hits, 1)
+		return hs, nil
+	}
+	gen := c.gen
+	c.mu.Unlock()
+	atomic.AddUint64(&c.misses, 1)
+
+	hs, err := c.next.GetHeroes(ctx)
+	if err != nil {
+		return hs, err
+	}
+
+	c.mu.Lock()
+	if gen == c.gen {
+		c.heroes = copyHeroes(hs)
+		c.heroesExpires = c.now().Add(c.listTTL)
+	}
+	c.mu.Unlock()
+
+	return hs, nil
+}
+
+// GetHero gets hero by ID from cache or from underlying storage
+func (c *Cache) GetHero(ctx context.Context, id string) (storage.Hero, error) {
+	c.mu.Lock()
+	if el, ok := c.items[id]; ok {
+		e := el.Value.(*entry)
+		if c.now().Before(e.expires) {
+			c.ll.MoveToFront(el)
+			c.mu.Unlock()
+			atomic.AddUint64(&c.hits, 1)
+			return e.hero, nil
+		}
+		c.removeElement(el)
+	}
+	gen := c.gen
+	c.mu.Unlock()
+	atomic.AddUint64(&c.misses, 1)
+
+	h, err := c.next.GetHero(ctx, id)
+	if err != nil {
+		return h, err
+	}
+
+	c.mu.Lock()
+	if gen == c.gen {
+		c.add(id, h)
+	}
+	c.mu.Unlock()
+
+	return h, nil
+}
+
+// GetHeroesByID gets cached heroes and fetches the rest from underlying storage
+// in single call
+func (c *Cache) GetHeroesByID(ctx context.Context, ids []string) ([]storage.Hero, error) {
+	heroes := make([]storage.Hero, 0, len(ids))
+	var missing []string
+
+	c.mu.Lock()
+	now := c.now()
+	for _, id := range ids {
+		if el, ok := c.items[id]; ok {
+			e := el.Value.(*entry)
+			if now.Before(e.expires) {
+				c.ll.MoveToFront(el)
+				heroes = append(heroes, e.hero)
+				continue
+			}
+			c.removeElement(el)
+		}
+		missing = append(missing, id)
+	}
+	gen := c.gen
+	c.mu.Unlock()
+
+	atomic.AddUint64(&c.hits, uint64(len(heroes)))
+	if len(missing) == 0 {
+		return heroes, nil
+	}
+	atomic.AddUint64(&c.misses, uint64(len(missing)))
+
+	hs, err := c.next.GetHeroesByID(ctx, missing)
+	if err != nil {
+		return hs, err
+	}
+
+	c.mu.Lock()
+	if gen == c.gen {
+		for _, h := range hs {
+			c.add(h.ID, h)
+		}
+	}
+	c.mu.Unlock()
+
+	return append(heroes, hs...), nil
+}
+
+// GetHeroesByOwner gets heroes of owner from underlying storage
+// sets of owned heroes are not cached
+func (c *Cache) GetHeroesByOwner(ctx context.Context, owner string) ([]storage.Hero, error) {
+	return c.next.GetHeroesByOwner(ctx, owner)
+}
+
+// CreateHero creates hero in underlying storage and invalidates cached data
+func (c *Cache) CreateHero(ctx context.Context, id, name string, owner storage.Owner) error {
+	defer c.Invalidate(id)
+	return c.next.CreateHero(ctx, id, name, owner)
+}
+
+// UpdateHero updates hero in underlying storage and invalidates cached data
+func (c *Cache) UpdateHero(ctx context.Context, id, name string, owner storage.Owner) error {
+	defer c.Invalidate(id)
+	return c.next.UpdateHero(ctx, id, name, owner)
+}
+
+// DeleteHero deletes hero in underlying storage and invalidates cached data
+func (c *Cache) DeleteHero(ctx context.Context, id string, owner storage.Owner) error {
+	defer c.Invalidate(id)
+	return c.next.DeleteHero(ctx, id, owner)
+}
+
+// Close closes underlying storage
+func (c *Cache) Close() error {
+	return c.next.Close()
+}
+
+// Invalidate removes hero and list of heroes from cache
+func (c *Cache) Invalidate(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.gen++
+	if el, ok := c.items[id]; ok {
+		c.removeElement(el)
+	}
+	c.heroes = nil
+}
+
+// Stats returns cache hit and miss counters
+// together with stats of underlying storage
+func (c *Cache) Stats() map[string]uint64 {
+	stats := map[string]uint64{}
+	if sr, ok := c.next.(storage.StatsReporter); ok {
+		stats = sr.Stats()
+	}
+	stats["cache_hits"] = atomic.LoadUint64(&c.hits)
+	stats["cache_misses"] = atomic.LoadUint64(&c.misses)
+	return stats
+}
+
+func (c *Cache) add(id string, h storage.Hero) {
+	if c.size <= 0 {
+		return
+	}
+
+	e := &entry{id: id, hero: h, expires: c.now().Add(c.ttl)}
+	if el, ok := c.items[id]; ok {
+		el.Value = e
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	c.items[id] = c.ll.PushFront(e)
+	if c.ll.Len() > c.size {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+func (c *Cache) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*entry).id)
+}
+
+func copyHeroes(hs []storage.Hero) []storage.Hero {
+	cp := make([]storage.Hero, len(hs))
+	copy(cp, hs)
+	return cp
+}
@@ -0,0 +1,154 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/storage"
+	stmocks "github.com/bliuchak/heroes/internal/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+func newTestCache(s storage.Storager, size int, listTTL time.Duration) (*Cache, *clock) {
+	clk := &clock{t: time.Unix(0, 0)}
+	c := New(s, size, time.Minute, listTTL)
+	c.now = clk.now
+	return c, clk
+}
+
+func TestCache_GetHero(t *testing.T) {
+	s := new(stmocks.Storager)
+	s.On("GetHero", mock.Anything, "1").Return(storage.Hero{ID: "1", Name: "Batman"}, nil).Once()
+	s.On("GetHero", mock.Anything, "2").Return(storage.Hero{}, storage.NewError(storage.ErrNotFound, "GetHero", "2", nil)).Twice()
+
+	c, _ := newTestCache(s, 10, 0)
+
+	for i := 0; i < 3; i++ {
+		h, err := c.GetHero(context.Background(), "1")
+		assert.NoError(t, err)
+		assert.Equal(t, storage.Hero{ID: "1", Name: "Batman"}, h)
+	}
+
+	// errors are never cached
+	for i := 0; i < 2; i++ {
+		_, err := c.GetHero(context.Background(), "2")
+		assert.Error(t, err)
+	}
+
+	s.AssertExpectations(t)
+	assert.Equal(t, map[string]uint64{"cache_hits": 2, "cache_misses": 3}, c.Stats())
+}
+
+func TestCache_GetHeroTTL(t *testing.T) {
+	s := new(stmocks.Storager)
+	s.On("GetHero", mock.Anything, "1").Return(storage.Hero{ID: "1", Name: "Batman"}, nil).Twice()
+
+	c, clk := newTestCache(s, 10, 0)
+
+	c.GetHero(context.Background(), "1")
+	clk.t = clk.t.Add(2 * time.Minute)
+	c.GetHero(context.Background(), "1")
+
+	s.AssertExpectations(t)
+}
+
+func TestCache_GetHeroEviction(t *testing.T) {
+	s := new(stmocks.Storager)
+	s.On("GetHero", mock.Anything, "1").Return(storage.Hero{ID: "1", Name: "Batman"}, nil).Twice()
+	s.On("GetHero", mock.Anything, "2").Return(storage.Hero{ID: "2", Name: "Superman"}, nil).Once()
+	s.On("GetHero", mock.Anything, "3").Return(storage.Hero{ID: "3", Name: "Flash"}, nil).Once()
+
+	c, _ := newTestCache(s, 2, 0)
+
+	c.GetHero(context.Background(), "1")
+	c.GetHero(context.Background(), "2")
+	c.GetHero(context.Background(), "2")
+	// "1" is least recently used and will be evicted
+	c.GetHero(context.Background(), "3")
+	c.GetHero(context.Background(), "1")
+
+	s.AssertExpectations(t)
+}
+
+func TestCache_GetHeroes(t *testing.T) {
+	heroes := []storage.Hero{{ID: "1", Name: "Batman"}}
+
+	s := new(stmocks.Storager)
+	s.On("GetHeroes", mock.Anything).Return(heroes, nil).Twice()
+	s.On("CreateHero", mock.Anything, "2", "Superman", storage.Owner{ID: "apikey:1"}).Return(nil).Once()
+
+	c, clk := newTestCache(s, 10, time.Second)
+
+	c.GetHeroes(context.Background())
+	hs, err := c.GetHeroes(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, heroes, hs)
+
+	// list is invalidated on write
+	c.CreateHero(context.Background(), "2", "Superman", storage.Owner{ID: "apikey:1"})
+	c.GetHeroes(context.Background())
+
+	clk.t = clk.t.Add(500 * time.Millisecond)
+	c.GetHeroes(context.Background())
+
+	s.AssertExpectations(t)
+}
+
+func TestCache_GetHeroesDisabled(t *testing.T) {
+	s := new(stmocks.Storager)
+	s.On("GetHeroes", mock.Anything).Return(nil, errors.New("scan error")).Twice()
+
+	c, _ := newTestCache(s, 10, 0)
+
+	c.GetHeroes(context.Background())
+	c.GetHeroes(context.Background())
+
+	s.AssertExpectations(t)
+}
+
+func TestCache_Invalidate(t *testing.T) {
+	s := new(stmocks.Storager)
+	s.On("GetHero", mock.Anything, "1").Return(storage.Hero{ID: "1", Name: "Batman"}, nil).Times(3)
+	s.On("DeleteHero", mock.Anything, "1", storage.Owner{ID: "apikey:1"}).Return(nil).Once()
+
+	c, _ := newTestCache(s, 10, 0)
+
+	c.GetHero(context.Background(), "1")
+	c.DeleteHero(context.Background(), "1", storage.Owner{ID: "apikey:1"})
+	c.GetHero(context.Background(), "1")
+	c.Invalidate("1")
+	c.GetHero(context.Background(), "1")
+
+	s.AssertExpectations(t)
+}
+
+func TestCache_GetHeroesByID(t *testing.T) {
+	s := new(stmocks.Storager)
+	s.On("GetHero", mock.Anything, "1").Return(storage.Hero{ID: "1", Name: "Batman"}, nil).Once()
+	s.On("GetHeroesByID", mock.Anything, []string{"2", "3"}).Return([]storage.Hero{{ID: "2", Name: "Superman"}}, nil).Once()
+	s.On("GetHeroesByID", mock.Anything, []string{"3"}).Return([]storage.Hero{}, nil).Once()
+
+	c, _ := newTestCache(s, 10, 0)
+
+	c.GetHero(context.Background(), "1")
+
+	hs, err := c.GetHeroesByID(context.Background(), []string{"1", "2", "3"})
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.Hero{{ID: "1", Name: "Batman"}, {ID: "2", Name: "Superman"}}, hs)
+
+	// only hero which doesn't exist is requested again
+	c.GetHeroesByID(context.Background(), []string{"1", "2", "3"})
+
+	s.AssertExpectations(t)
+}
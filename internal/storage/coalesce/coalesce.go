@@ -0,0 +1,144 @@
+package coalesce
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/bliuchak/heroes/internal/storage"
+	"golang.org/x/sync/singleflight"
+)
+
+const heroesKey = "heroes"
+
+// Coalescer is a storage.Storager decorator which lets concurrent identical
+// reads share single call to underlying storage
+// results and errors are fanned out to every waiter but never cached
+type Coalescer struct {
+	next  storage.Storager
+	group singleflight.Group
+
+	shared uint64
+	// waiting is number of callers which wait for result at the moment
+	waiting int64
+}
+
+// New returns pointer to Coalescer which wraps next storage
+func New(next storage.Storager) *Coalescer {
+	return &Coalescer{next: next}
+}
+
+// Status checks storage connection status
+func (c *Coalescer) Status(ctx context.Context) (string, error) {
+	return c.next.Status(ctx)
+}
+
+// GetHeroes gets all heroes, concurrent calls share single storage call
+func (c *Coalescer) GetHeroes(ctx context.Context) ([]storage.Hero, error) {
+	v, err := c.do(ctx, heroesKey, func(ctx context.Context) (interface{}, error) {
+		return c.next.GetHeroes(ctx)
+	})
+
+	hs, _ := v.([]storage.Hero)
+	if err != nil {
+		return hs, err
+	}
+
+	// every waiter gets own copy so callers can't modify each other result
+	cp := make([]storage.Hero, len(hs))
+	copy(cp, hs)
+	return cp, nil
+}
+
+// GetHero gets hero by ID, concurrent calls for the same ID share single storage call
+func (c *Coalescer) GetHero(ctx context.Context, id string) (storage.Hero, error) {
+	v, err := c.do(ctx, heroKey(id), func(ctx context.Context) (interface{}, error) {
+		return c.next.GetHero(ctx, id)
+	})
+
+	h, _ := v.(storage.Hero)
+	return h, err
+}
+
+// do runs fn once for all concurrent callers with the same key
+// shared call isn't canceled when one of callers gives up, so it runs without
+// cancellation of caller context (storage still applies own timeout) while
+// every caller waits only until its own context is done
+func (c *Coalescer) do(ctx context.Context, key string, fn func(context.Context) (interface{}, error)) (interface{}, error) {
+	ch := c.group.DoChan(key, func() (interface{}, error) {
+		return fn(context.WithoutCancel(ctx))
+	})
+	atomic.AddInt64(&c.waiting, 1)
+	defer atomic.AddInt64(&c.waiting, -1)
+
+	select {
+	case res := <-ch:
+		if res.Shared {
+			atomic.AddUint64(&c.shared, 1)
+		}
+		return res.Val, res.Err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// GetHeroesByID gets heroes with given IDs
+// batch requests are rarely identical so they are not coalesced
+func (c *Coalescer) GetHeroesByID(ctx context.Context, ids []string) ([]storage.Hero, error) {
+	return c.next.GetHeroesByID(ctx, ids)
+}
+
+// GetHeroesByOwner gets heroes of owner
+// requests of different clients are rarely identical so they are not coalesced
+func (c *Coalescer) GetHeroesByOwner(ctx context.Context, owner string) ([]storage.Hero, error) {
+	return c.next.GetHeroesByOwner(ctx, owner)
+}
+
+// CreateHero creates hero in underlying storage
+// reads started before the write are not shared with later callers
+func (c *Coalescer) CreateHero(ctx context.Context, id, name string, owner storage.Owner) error {
+	err := c.next.CreateHero(ctx, id, name, owner)
+	c.forget(id)
+	return err
+}
+
+// UpdateHero updates hero in underlying storage
+// reads started before the write are not shared with later callers
+func (c *Coalescer) UpdateHero(ctx context.Context, id, name string, owner storage.Owner) error {
+	err := c.next.UpdateHero(ctx, id, name, owner)
+	c.forget(id)
+	return err
+}
+
+// DeleteHero deletes hero in underlying storage
+// reads started before the write are not shared with later callers
+func (c *Coalescer) DeleteHero(ctx context.Context, id string, owner storage.Owner) error {
+	err := c.next.DeleteHero(ctx, id, owner)
+	c.forget(id)
+	return err
+}
+
+// Close closes underlying storage
+func (c *Coalescer) Close() error {
+	return c.next.Close()
+}
+
+// Stats returns number of calls which received shared result
+// together with stats of underlying storage
+func (c *Coalescer) Stats() map[string]uint64 {
+	stats := map[string]uint64{}
+	if sr, ok := c.next.(storage.StatsReporter); ok {
+		stats = sr.Stats()
+	}
+	stats["coalesced"] = atomic.LoadUint64(&c.shared)
+	stats["waiting"] = uint64(atomic.LoadInt64(&c.waiting))
+	return stats
+}
+
+func (c *Coalescer) forget(id string) {
+	c.group.Forget(heroKey(id))
+	c.group.Forget(heroesKey)
+}
+
+func heroKey(id string) string {
+	return "hero." + id
+}
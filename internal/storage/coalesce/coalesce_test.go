@@ -0,0 +1,119 @@
+package coalesce
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/storage"
+	stmocks "github.com/bliuchak/heroes/internal/storage/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const waiters = 10
+
+// runConcurrently runs fn in waiters goroutines and waits until all of them finished
+func runConcurrently(fn func()) {
+	var wg sync.WaitGroup
+	wg.Add(waiters)
+	for i := 0; i < waiters; i++ {
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+	wg.Wait()
+}
+
+// releaseWhenJoined closes release once all waiters joined in-flight call
+func releaseWhenJoined(t *testing.T, c *Coalescer, release chan time.Time) {
+	assert.Eventually(t, func() bool {
+		return c.Stats()["waiting"] == waiters
+	}, time.Second, time.Millisecond)
+	close(release)
+}
+
+func TestCoalescer_GetHero(t *testing.T) {
+	release := make(chan time.Time)
+
+	s := new(stmocks.Storager)
+	s.On("GetHero", mock.Anything, "1").WaitUntil(release).Return(storage.Hero{ID: "1", Name: "Batman"}, nil).Once()
+
+	c := New(s)
+
+	go releaseWhenJoined(t, c, release)
+
+	runConcurrently(func() {
+		h, err := c.GetHero(context.Background(), "1")
+		assert.NoError(t, err)
+		assert.Equal(t, storage.Hero{ID: "1", Name: "Batman"}, h)
+	})
+
+	s.AssertExpectations(t)
+	assert.Equal(t, uint64(waiters), c.Stats()["coalesced"])
+}
+
+func TestCoalescer_GetHeroesError(t *testing.T) {
+	release := make(chan time.Time)
+
+	s := new(stmocks.Storager)
+	s.On("GetHeroes", mock.Anything).WaitUntil(release).Return(nil, errors.New("scan error")).Once()
+	s.On("GetHeroes", mock.Anything).Return([]storage.Hero{{ID: "1", Name: "Batman"}}, nil).Once()
+
+	c := New(s)
+
+	go releaseWhenJoined(t, c, release)
+
+	runConcurrently(func() {
+		_, err := c.GetHeroes(context.Background())
+		assert.EqualError(t, err, "scan error")
+	})
+
+	// error is not cached, next call goes to storage
+	hs, err := c.GetHeroes(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []storage.Hero{{ID: "1", Name: "Batman"}}, hs)
+
+	s.AssertExpectations(t)
+}
+
+func TestCoalescer_Writes(t *testing.T) {
+	s := new(stmocks.Storager)
+	s.On("CreateHero", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+	s.On("DeleteHero", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("DEL error")).Once()
+
+	c := New(s)
+
+	assert.NoError(t, c.CreateHero(context.Background(), "1", "Batman", storage.Owner{}))
+	assert.EqualError(t, c.DeleteHero(context.Background(), "1", storage.Owner{}), "DEL error")
+
+	s.AssertExpectations(t)
+}
+
+func TestCoalescer_GetHeroCanceled(t *testing.T) {
+	release := make(chan time.Time)
+	done := make(chan struct{})
+
+	s := new(stmocks.Storager)
+	s.On("GetHero", mock.Anything, "1").WaitUntil(release).Return(storage.Hero{ID: "1", Name: "Batman"}, nil).Once().
+		Run(func(args mock.Arguments) {
+			// shared call doesn't inherit cancellation of the first caller
+			assert.NoError(t, args.Get(0).(context.Context).Err())
+			close(done)
+		})
+
+	c := New(s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := c.GetHero(ctx, "1")
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	close(release)
+	<-done
+	s.AssertExpectations(t)
+}
@@ -1,34 +1,113 @@
 package storage
 
-// ErrNothingToDelete custom error type for Hero handlers
-// it tells that hero which requested to be deleted not exists
-type ErrNothingToDelete struct {
-	message string
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Kind classifies storage errors, so transport layers (HTTP, gRPC, GraphQL)
+// can map them without knowing which backend is used.
+// Kinds implement error, so they can be used as errors.Is target:
+//
+//	if errors.Is(err, storage.ErrNotFound) { ... }
+type Kind uint8
+
+// kinds of storage errors
+const (
+	// ErrInternal is unexpected failure, e.g. unknown driver error
+	ErrInternal Kind = iota
+	// ErrNotFound tells that requested hero doesn't exist
+	ErrNotFound
+	// ErrConflict tells that operation conflicts with current state of data
+	ErrConflict
+	// ErrPreconditionFailed tells that condition of conditional operation is not met
+	ErrPreconditionFailed
+	// ErrUnavailable tells that storage can't be reached, operation may be retried
+	ErrUnavailable
+	// ErrInvalid tells that storage refused given arguments
+	ErrInvalid
+	// ErrForbidden tells that caller isn't allowed to write data, e.g. hero
+	// of other owner
+	ErrForbidden
+)
+
+var kindNames = map[Kind]string{
+	ErrInternal:           "internal error",
+	ErrNotFound:           "not found",
+	ErrConflict:           "conflict",
+	ErrPreconditionFailed: "precondition failed",
+	ErrUnavailable:        "unavailable",
+	ErrInvalid:            "invalid",
+	ErrForbidden:          "forbidden",
 }
 
-// NewErrNothingToDelete returns pointer with error message to ErrNothingToDelete
-func NewErrNothingToDelete(message string) *ErrNothingToDelete {
-	return &ErrNothingToDelete{
-		message: message,
-	}
+func (k Kind) String() string {
+	return kindNames[k]
 }
 
-func (e *ErrNothingToDelete) Error() string {
-	return e.message
+func (k Kind) Error() string {
+	return k.String()
 }
 
-// ErrHeroNotExist custom error for Hero handlers
-// it tells that requested hero is not existing
-type ErrHeroNotExist struct {
-	message string
+// Error is error returned by storage backends
+// it wraps underlying driver error (if any), so it's still reachable
+// with errors.Is/As
+type Error struct {
+	Kind Kind
+	// Op is storage operation which failed, e.g. GetHero
+	Op string
+	// ID of hero operation was called with
+	ID  string
+	Err error
 }
 
-// NewErrHeroNotExist returns pointer with error message to ErrHeroNotExist
-func NewErrHeroNotExist(message string) *ErrHeroNotExist {
-	return &ErrHeroNotExist{
-		message: message,
+// NewError returns pointer to Error
+func NewError(kind Kind, op, id string, err error) *Error {
+	return &Error{Kind: kind, Op: op, ID: id, Err: err}
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString("storage: ")
+	if e.Op != "" {
+		b.WriteString(e.Op + " ")
+	}
+	if e.ID != "" {
+		b.WriteString(e.ID + " ")
+	}
+	b.WriteString(e.Kind.String())
+	if e.Err != nil {
+		b.WriteString(": " + e.Err.Error())
 	}
+	return b.String()
 }
-func (e *ErrHeroNotExist) Error() string {
-	return e.message
+
+// Unwrap returns underlying driver error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether error is of target kind
+func (e *Error) Is(target error) bool {
+	k, ok := target.(Kind)
+	return ok && k == e.Kind
+}
+
+// KindOf returns kind of storage error, canceled or expired context means
+// that storage didn't answer in time (ErrUnavailable), other errors which are
+// not storage errors are ErrInternal
+func KindOf(err error) Kind {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Kind
+	}
+	var k Kind
+	if errors.As(err, &k) {
+		return k
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return ErrUnavailable
+	}
+	return ErrInternal
 }
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestError(t *testing.T) {
+	err := fmt.Errorf("handler: %w", NewError(ErrUnavailable, "GetHero", "1", io.EOF))
+
+	assert.True(t, errors.Is(err, ErrUnavailable))
+	assert.False(t, errors.Is(err, ErrNotFound))
+	assert.True(t, errors.Is(err, io.EOF))
+
+	var se *Error
+	assert.True(t, errors.As(err, &se))
+	assert.Equal(t, "GetHero", se.Op)
+	assert.Equal(t, "handler: storage: GetHero 1 unavailable: EOF", err.Error())
+}
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected Kind
+	}{
+		{name: "should return kind of storage error", err: NewError(ErrNotFound, "DeleteHero", "1", nil), expected: ErrNotFound},
+		{name: "should return kind itself", err: ErrConflict, expected: ErrConflict},
+		{name: "should return kind of wrapped error", err: fmt.Errorf("wrap: %w", ErrInvalid), expected: ErrInvalid},
+		{name: "should treat unknown error as internal", err: errors.New("boom"), expected: ErrInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, KindOf(tt.err))
+		})
+	}
+}
@@ -0,0 +1,128 @@
+// Package instrument provides storage.Storager decorator which records
+// latency and errors of every storage call and traces it
+package instrument
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/bliuchak/heroes/internal/metrics"
+	"github.com/bliuchak/heroes/internal/storage"
+	"github.com/bliuchak/heroes/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Instrumented is a storage.Storager decorator which observes every call
+// of underlying storage, each call gets own span
+type Instrumented struct {
+	next    storage.Storager
+	metrics *metrics.Metrics
+}
+
+// New returns pointer to Instrumented which wraps next storage, calls are
+// only traced when m is nil
+func New(next storage.Storager, m *metrics.Metrics) *Instrumented {
+	return &Instrumented{next: next, metrics: m}
+}
+
+// Status checks storage connection status
+func (s *Instrumented) Status(ctx context.Context) (status string, err error) {
+	ctx, end := s.start(ctx, "Status")
+	defer end(&err)
+	return s.next.Status(ctx)
+}
+
+// GetHeroes gets all heroes
+func (s *Instrumented) GetHeroes(ctx context.Context) (heroes []storage.Hero, err error) {
+	ctx, end := s.start(ctx, "GetHeroes")
+	defer end(&err)
+	return s.next.GetHeroes(ctx)
+}
+
+// GetHero gets hero by ID
+func (s *Instrumented) GetHero(ctx context.Context, id string) (hero storage.Hero, err error) {
+	ctx, end := s.start(ctx, "GetHero")
+	defer end(&err)
+	return s.next.GetHero(ctx, id)
+}
+
+// GetHeroesByID gets heroes with given IDs
+func (s *Instrumented) GetHeroesByID(ctx context.Context, ids []string) (heroes []storage.Hero, err error) {
+	ctx, end := s.start(ctx, "GetHeroesByID")
+	defer end(&err)
+	return s.next.GetHeroesByID(ctx, ids)
+}
+
+// GetHeroesByOwner gets heroes of owner
+func (s *Instrumented) GetHeroesByOwner(ctx context.Context, owner string) (heroes []storage.Hero, err error) {
+	ctx, end := s.start(ctx, "GetHeroesByOwner")
+	defer end(&err)
+	return s.next.GetHeroesByOwner(ctx, owner)
+}
+
+// CreateHero creates hero
+func (s *Instrumented) CreateHero(ctx context.Context, id, name string, owner storage.Owner) (err error) {
+	ctx, end := s.start(ctx, "CreateHero")
+	defer end(&err)
+	return s.next.CreateHero(ctx, id, name, owner)
+}
+
+// UpdateHero updates hero
+func (s *Instrumented) UpdateHero(ctx context.Context, id, name string, owner storage.Owner) (err error) {
+	ctx, end := s.start(ctx, "UpdateHero")
+	defer end(&err)
+	return s.next.UpdateHero(ctx, id, name, owner)
+}
+
+// DeleteHero deletes hero
+func (s *Instrumented) DeleteHero(ctx context.Context, id string, owner storage.Owner) (err error) {
+	ctx, end := s.start(ctx, "DeleteHero")
+	defer end(&err)
+	return s.next.DeleteHero(ctx, id, owner)
+}
+
+// Close closes underlying storage
+func (s *Instrumented) Close() error {
+	return s.next.Close()
+}
+
+// Stats returns stats of underlying storage
+func (s *Instrumented) Stats() map[string]uint64 {
+	if sr, ok := s.next.(storage.StatsReporter); ok {
+		return sr.Stats()
+	}
+	return map[string]uint64{}
+}
+
+// start starts span of storage call, returned function ends it and records
+// latency and error of call
+func (s *Instrumented) start(ctx context.Context, method string) (context.Context, func(err *error)) {
+	ctx, span := tracing.Tracer().Start(ctx, "storage."+method,
+		trace.WithAttributes(attribute.String("storage.method", method)))
+	start := time.Now()
+
+	return ctx, func(err *error) {
+		defer span.End()
+
+		var kind storage.Kind
+		if *err != nil {
+			kind = storage.KindOf(*err)
+			span.RecordError(*err)
+			// missing or forbidden hero is answer, not failure of storage
+			if kind != storage.ErrNotFound && kind != storage.ErrForbidden {
+				span.SetStatus(codes.Error, kind.String())
+			}
+		}
+
+		if s.metrics == nil {
+			return
+		}
+		s.metrics.StorageDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+		if *err != nil {
+			s.metrics.StorageErrors.WithLabelValues(method, strings.ReplaceAll(kind.String(), " ", "_")).Inc()
+		}
+	}
+}
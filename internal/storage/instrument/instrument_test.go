@@ -0,0 +1,64 @@
+package instrument
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bliuchak/heroes/internal/metrics"
+	"github.com/bliuchak/heroes/internal/storage"
+	stmocks "github.com/bliuchak/heroes/internal/storage/mocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestInstrumented(t *testing.T) {
+	sr := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(sr)))
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	owner := storage.Owner{ID: "apikey:1"}
+
+	st := new(stmocks.Storager)
+	st.On("GetHero", mock.Anything, "1").Return(storage.Hero{ID: "1", Name: "Batman"}, nil)
+	st.On("GetHero", mock.Anything, "2").Return(storage.Hero{}, storage.NewError(storage.ErrNotFound, "GetHero", "2", nil))
+	st.On("DeleteHero", mock.Anything, "1", owner).Return(errors.New("connection refused"))
+
+	m := metrics.New()
+	s := New(st, m)
+
+	h, err := s.GetHero(context.Background(), "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Batman", h.Name)
+	_, err = s.GetHero(context.Background(), "2")
+	assert.True(t, errors.Is(err, storage.ErrNotFound))
+	assert.Error(t, s.DeleteHero(context.Background(), "1", owner))
+
+	assert.Equal(t, 2, testutil.CollectAndCount(m.StorageDuration))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.StorageErrors.WithLabelValues("GetHero", "not_found")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(m.StorageErrors.WithLabelValues("DeleteHero", "internal_error")))
+
+	spans := sr.Ended()
+	if assert.Len(t, spans, 3) {
+		assert.Equal(t, "storage.GetHero", spans[0].Name())
+		assert.Equal(t, codes.Unset, spans[1].Status().Code)
+		assert.Equal(t, "storage.DeleteHero", spans[2].Name())
+		assert.Equal(t, codes.Error, spans[2].Status().Code)
+	}
+	st.AssertExpectations(t)
+}
+
+func TestInstrumented_WithoutMetrics(t *testing.T) {
+	st := new(stmocks.Storager)
+	st.On("GetHeroes", mock.Anything).Return([]storage.Hero{{ID: "1", Name: "Batman"}}, nil)
+
+	heroes, err := New(st, nil).GetHeroes(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, heroes, 1)
+}
@@ -1,6 +1,7 @@
 // Code generated by mockery v1.0.0. DO NOT EDIT.
 package mocks
 
+import context "context"
 import mock "github.com/stretchr/testify/mock"
 import storage "github.com/bliuchak/heroes/internal/storage"
 
@@ -9,13 +10,27 @@ type Storager struct {
 	mock.Mock
 }
 
-// CreateHero provides a mock function with given fields: id, name
-func (_m *Storager) CreateHero(id string, name string) error {
-	ret := _m.Called(id, name)
+// Close provides a mock function with given fields:
+func (_m *Storager) Close() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateHero provides a mock function with given fields: ctx, id, name, owner
+func (_m *Storager) CreateHero(ctx context.Context, id string, name string, owner storage.Owner) error {
+	ret := _m.Called(ctx, id, name, owner)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, string) error); ok {
-		r0 = rf(id, name)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, storage.Owner) error); ok {
+		r0 = rf(ctx, id, name, owner)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -23,13 +38,13 @@ func (_m *Storager) CreateHero(id string, name string) error {
 	return r0
 }
 
-// DeleteHero provides a mock function with given fields: id
-func (_m *Storager) DeleteHero(id string) error {
-	ret := _m.Called(id)
+// DeleteHero provides a mock function with given fields: ctx, id, owner
+func (_m *Storager) DeleteHero(ctx context.Context, id string, owner storage.Owner) error {
+	ret := _m.Called(ctx, id, owner)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(context.Context, string, storage.Owner) error); ok {
+		r0 = rf(ctx, id, owner)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -37,20 +52,20 @@ func (_m *Storager) DeleteHero(id string) error {
 	return r0
 }
 
-// GetHero provides a mock function with given fields: name
-func (_m *Storager) GetHero(name string) (storage.Hero, error) {
-	ret := _m.Called(name)
+// GetHero provides a mock function with given fields: ctx, name
+func (_m *Storager) GetHero(ctx context.Context, name string) (storage.Hero, error) {
+	ret := _m.Called(ctx, name)
 
 	var r0 storage.Hero
-	if rf, ok := ret.Get(0).(func(string) storage.Hero); ok {
-		r0 = rf(name)
+	if rf, ok := ret.Get(0).(func(context.Context, string) storage.Hero); ok {
+		r0 = rf(ctx, name)
 	} else {
 		r0 = ret.Get(0).(storage.Hero)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(string) error); ok {
-		r1 = rf(name)
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -58,13 +73,13 @@ func (_m *Storager) GetHero(name string) (storage.Hero, error) {
 	return r0, r1
 }
 
-// GetHeroes provides a mock function with given fields:
-func (_m *Storager) GetHeroes() ([]storage.Hero, error) {
-	ret := _m.Called()
+// GetHeroes provides a mock function with given fields: ctx
+func (_m *Storager) GetHeroes(ctx context.Context) ([]storage.Hero, error) {
+	ret := _m.Called(ctx)
 
 	var r0 []storage.Hero
-	if rf, ok := ret.Get(0).(func() []storage.Hero); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) []storage.Hero); ok {
+		r0 = rf(ctx)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]storage.Hero)
@@ -72,8 +87,8 @@ func (_m *Storager) GetHeroes() ([]storage.Hero, error) {
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -81,23 +96,83 @@ func (_m *Storager) GetHeroes() ([]storage.Hero, error) {
 	return r0, r1
 }
 
-// Status provides a mock function with given fields:
-func (_m *Storager) Status() (string, error) {
-	ret := _m.Called()
+// GetHeroesByID provides a mock function with given fields: ctx, ids
+func (_m *Storager) GetHeroesByID(ctx context.Context, ids []string) ([]storage.Hero, error) {
+	ret := _m.Called(ctx, ids)
+
+	var r0 []storage.Hero
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []storage.Hero); ok {
+		r0 = rf(ctx, ids)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Hero)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetHeroesByOwner provides a mock function with given fields: ctx, owner
+func (_m *Storager) GetHeroesByOwner(ctx context.Context, owner string) ([]storage.Hero, error) {
+	ret := _m.Called(ctx, owner)
+
+	var r0 []storage.Hero
+	if rf, ok := ret.Get(0).(func(context.Context, string) []storage.Hero); ok {
+		r0 = rf(ctx, owner)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Hero)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, owner)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Status provides a mock function with given fields: ctx
+func (_m *Storager) Status(ctx context.Context) (string, error) {
+	ret := _m.Called(ctx)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func() string); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(context.Context) string); ok {
+		r0 = rf(ctx)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
 
 	return r0, r1
 }
+
+// UpdateHero provides a mock function with given fields: ctx, id, name, owner
+func (_m *Storager) UpdateHero(ctx context.Context, id string, name string, owner storage.Owner) error {
+	ret := _m.Called(ctx, id, name, owner)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, storage.Owner) error); ok {
+		r0 = rf(ctx, id, name, owner)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -1,18 +1,40 @@
 package storage
 
+import "context"
+
 // Storager general storage interface
+// implementations stop working on request as soon as ctx is done
 type Storager interface {
-	Status() (string, error)
-	GetHeroes() ([]Hero, error)
-	GetHero(name string) (Hero, error)
-	CreateHero(id, name string) error
-	DeleteHero(id string) error
+	Status(ctx context.Context) (string, error)
+	GetHeroes(ctx context.Context) ([]Hero, error)
+	GetHero(ctx context.Context, name string) (Hero, error)
+	GetHeroesByID(ctx context.Context, ids []string) ([]Hero, error)
+	GetHeroesByOwner(ctx context.Context, owner string) ([]Hero, error)
+	// CreateHero records owner as owner of new hero, existing hero keeps
+	// its owner and may be overwritten by owner or admin only
+	CreateHero(ctx context.Context, id, name string, owner Owner) error
+	// UpdateHero and DeleteHero return ErrForbidden when owner doesn't own hero
+	UpdateHero(ctx context.Context, id, name string, owner Owner) error
+	DeleteHero(ctx context.Context, id string, owner Owner) error
+	// Close releases connections, storage must not be used after it
+	Close() error
 }
 
 // Hero contains hero data
 type Hero struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID   string `json:"id" xml:"id"`
+	Name string `json:"name" xml:"name"`
+	// Owner is subject of client which created hero, heroes created before
+	// ownership was introduced have no owner and may be written by admin only
+	Owner string `json:"owner,omitempty" xml:"owner,omitempty" yaml:"owner,omitempty"`
+}
+
+// Owner is client on whose behalf heroes are written
+type Owner struct {
+	// ID is recorded as owner of created heroes
+	ID string
+	// Admin may write heroes of other owners
+	Admin bool
 }
 
 // IsValid validates hero structure
@@ -25,3 +47,8 @@ func (h *Hero) IsValid() bool {
 	}
 	return true
 }
+
+// StatsReporter is implemented by storages which collect usage statistics
+type StatsReporter interface {
+	Stats() map[string]uint64
+}
@@ -0,0 +1,140 @@
+// Package tracing sets up OpenTelemetry tracing with W3C trace context
+// propagation and pluggable span exporters
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/bliuchak/heroes/internal/version"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// name is name of instrumentation scope of application spans
+const name = "github.com/bliuchak/heroes"
+
+// Tracer returns tracer of application spans, it's no-op until Setup
+// installs exporter
+func Tracer() trace.Tracer {
+	return otel.Tracer(name)
+}
+
+// ExporterFunc creates span exporter, meaning of target depends on
+// exporter, e.g. path of file or URL of collector
+type ExporterFunc func(ctx context.Context, target string) (sdktrace.SpanExporter, error)
+
+var exporters = map[string]ExporterFunc{
+	"stdout": func(ctx context.Context, target string) (sdktrace.SpanExporter, error) {
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	},
+	"file": newFileExporter,
+	"otlp": func(ctx context.Context, target string) (sdktrace.SpanExporter, error) {
+		// without target collector is configured by OTEL_EXPORTER_OTLP_* variables
+		var opts []otlptracehttp.Option
+		if target != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(target))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	},
+}
+
+// RegisterExporter makes exporter available by name, it's meant to be
+// called from init
+func RegisterExporter(name string, fn ExporterFunc) {
+	exporters[name] = fn
+}
+
+// Exporters returns names of available exporters
+func Exporters() []string {
+	names := make([]string, 0, len(exporters))
+	for name := range exporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Options describes where and which spans are exported
+type Options struct {
+	// Exporter is name of exporter, empty value or "none" disables export,
+	// but trace context of incoming requests is still propagated
+	Exporter string
+	Target   string
+	// SampleRatio is share of traces started by this service which are
+	// sampled, sampling decision of caller is always respected
+	SampleRatio float64
+}
+
+// Setup installs global tracer provider and W3C trace context propagator,
+// returned function flushes pending spans and stops exporter
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if opts.Exporter == "" || opts.Exporter == "none" {
+		return func(context.Context) error { return nil }, nil
+	}
+	newExporter, ok := exporters[opts.Exporter]
+	if !ok {
+		return nil, fmt.Errorf("unknown tracing exporter %q, available: %s", opts.Exporter, strings.Join(Exporters(), ", "))
+	}
+	exp, err := newExporter(ctx, opts.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", "heroes"),
+		attribute.String("service.version", version.Version),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown, nil
+}
+
+// fileExporter writes spans as JSON lines to file and closes it on shutdown
+type fileExporter struct {
+	sdktrace.SpanExporter
+	f *os.File
+}
+
+func newFileExporter(ctx context.Context, target string) (sdktrace.SpanExporter, error) {
+	if target == "" {
+		return nil, fmt.Errorf("file exporter requires path of file")
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	exp, err := stdouttrace.New(stdouttrace.WithWriter(f))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &fileExporter{SpanExporter: exp, f: f}, nil
+}
+
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	err := e.SpanExporter.Shutdown(ctx)
+	if cerr := e.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
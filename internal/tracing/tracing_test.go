@@ -0,0 +1,70 @@
+package tracing
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestSetup(t *testing.T) {
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	path := filepath.Join(t.TempDir(), "spans.json")
+	stop, err := Setup(context.Background(), Options{Exporter: "file", Target: path, SampleRatio: 1})
+	assert.NoError(t, err)
+
+	_, span := Tracer().Start(context.Background(), "storage.GetHero")
+	span.End()
+	assert.NoError(t, stop(context.Background()))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"Name":"storage.GetHero"`)
+}
+
+func TestSetup_Exporters(t *testing.T) {
+	t.Cleanup(func() { otel.SetTracerProvider(noop.NewTracerProvider()) })
+
+	exp := &countingExporter{InMemoryExporter: tracetest.NewInMemoryExporter()}
+	RegisterExporter("memory", func(ctx context.Context, target string) (sdktrace.SpanExporter, error) {
+		return exp, nil
+	})
+	defer delete(exporters, "memory")
+	assert.Equal(t, []string{"file", "memory", "otlp", "stdout"}, Exporters())
+
+	stop, err := Setup(context.Background(), Options{Exporter: "memory", SampleRatio: 1})
+	assert.NoError(t, err)
+	_, span := Tracer().Start(context.Background(), "GET /v1/hero/{id}")
+	span.End()
+	assert.NoError(t, stop(context.Background()))
+	assert.Equal(t, 1, exp.exported)
+
+	_, err = Setup(context.Background(), Options{Exporter: "jaeger"})
+	assert.EqualError(t, err, `unknown tracing exporter "jaeger", available: file, memory, otlp, stdout`)
+
+	_, err = Setup(context.Background(), Options{Exporter: "file"})
+	assert.Error(t, err)
+
+	stop, err = Setup(context.Background(), Options{Exporter: "none"})
+	assert.NoError(t, err)
+	assert.NoError(t, stop(context.Background()))
+}
+
+// countingExporter counts exported spans, in-memory exporter forgets them
+// on shutdown
+type countingExporter struct {
+	*tracetest.InMemoryExporter
+	exported int
+}
+
+func (e *countingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	e.exported += len(spans)
+	return e.InMemoryExporter.ExportSpans(ctx, spans)
+}
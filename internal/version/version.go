@@ -0,0 +1,39 @@
+// Package version describes running build of application
+package version
+
+import (
+	"runtime/debug"
+	"time"
+)
+
+// Version and Commit are set at build time, e.g.
+//
+//	go build -ldflags "-X github.com/bliuchak/heroes/internal/version.Version=1.0.0 -X github.com/bliuchak/heroes/internal/version.Commit=abc123"
+//
+// when Commit isn't set it's taken from VCS info embedded by go build (if any)
+var (
+	Version = "dev"
+	Commit  = ""
+)
+
+var started = time.Now()
+
+func init() {
+	if Commit != "" {
+		return
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	for _, s := range info.Settings {
+		if s.Key == "vcs.revision" {
+			Commit = s.Value
+		}
+	}
+}
+
+// Uptime returns how long application is running
+func Uptime() time.Duration {
+	return time.Since(started)
+}